@@ -0,0 +1,49 @@
+package conditionevaluator
+
+import "sync"
+
+// BaselineStore keeps the content baselines of one service, keyed by the
+// condition that owns them (usually a pointer). Replacements are staged and
+// applied by Commit, so a condition that is evaluated more than once for the
+// same check sees the same baseline.
+type BaselineStore struct {
+	mu      sync.Mutex
+	current map[any]string
+	pending map[any]string
+}
+
+func NewBaselineStore() *BaselineStore {
+	return &BaselineStore{
+		current: make(map[any]string),
+		pending: make(map[any]string),
+	}
+}
+
+// Get returns the baseline of the condition. The first body seen becomes the
+// baseline, in which case seen is false.
+func (s *BaselineStore) Get(cond any, body string) (baseline string, seen bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	baseline, seen = s.current[cond]
+	if !seen {
+		s.current[cond] = body
+	}
+	return baseline, seen
+}
+
+// Replace stages body as the new baseline of the condition.
+func (s *BaselineStore) Replace(cond any, body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending[cond] = body
+}
+
+// Commit applies the staged replacements; call it once per check.
+func (s *BaselineStore) Commit() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for cond, body := range s.pending {
+		s.current[cond] = body
+		delete(s.pending, cond)
+	}
+}
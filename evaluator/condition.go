@@ -1,9 +0,0 @@
-package conditionevaluator
-
-import (
-	"net/http"
-)
-
-type Evaluator interface {
-	Evaluate(resp *http.Response, body []byte) bool
-}
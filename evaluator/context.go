@@ -0,0 +1,58 @@
+package conditionevaluator
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Context is everything a condition may look at for one check.
+type Context struct {
+	Response *http.Response
+	Body     []byte
+	Duration time.Duration
+	Timing   Timing
+	// History holds the service's recent checks, including the current one.
+	// It is nil while deciding whether the current check counts as a success.
+	History *History
+	// Baselines holds the service's content_drift baselines; nil disables
+	// drift detection unless a baseline file or hash is configured.
+	Baselines *BaselineStore
+	// Redirects lists the redirects followed before Response, in order.
+	Redirects []RedirectHop
+}
+
+// Timing splits a request into its phases, as recorded with net/http/httptrace.
+// TTFB is measured from the request being written to the first response byte,
+// so it reflects server processing rather than network setup.
+type Timing struct {
+	DNS      time.Duration `json:"dns"`
+	Connect  time.Duration `json:"connect"`
+	TLS      time.Duration `json:"tls"`
+	TTFB     time.Duration `json:"ttfb"`
+	Download time.Duration `json:"download"`
+}
+
+func (t Timing) String() string {
+	r := func(d time.Duration) time.Duration { return d.Round(time.Millisecond) }
+	return fmt.Sprintf("dns=%v connect=%v tls=%v ttfb=%v download=%v",
+		r(t.DNS), r(t.Connect), r(t.TLS), r(t.TTFB), r(t.Download))
+}
+
+// RedirectHop is one redirect followed by the client.
+type RedirectHop struct {
+	From       string `json:"from"`
+	To         string `json:"to"`
+	StatusCode int    `json:"status_code"`
+}
+
+// FinalURL is the URL of the response that ended the chain.
+func (ctx *Context) FinalURL() string {
+	if ctx.Response != nil && ctx.Response.Request != nil && ctx.Response.Request.URL != nil {
+		return ctx.Response.Request.URL.String()
+	}
+	if n := len(ctx.Redirects); n > 0 {
+		return ctx.Redirects[n-1].To
+	}
+	return ""
+}
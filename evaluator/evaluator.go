@@ -0,0 +1,79 @@
+// Package conditionevaluator is the extension point for condition types.
+// A condition type registers a Factory under its YAML key; condition nodes
+// using that key are built by the factory when the config is parsed,
+// validated at load time and evaluated against the Context of every check:
+//
+//	func init() {
+//		conditionevaluator.Register("graphql_ok", func(config conditionevaluator.Config) (conditionevaluator.Evaluator, error) {
+//			c := &graphQLCondition{}
+//			return c, config.Decode(c)
+//		})
+//	}
+//
+// Every built-in leaf condition, from regex and status_code to window and
+// redirect, is registered the same way by package model.
+package conditionevaluator
+
+import (
+	"fmt"
+	"time"
+
+	"healthy-api/registry"
+)
+
+// Evaluator is one configured condition node.
+type Evaluator interface {
+	// Validate is called once when the config is loaded. It is the place
+	// to compile patterns and parse durations.
+	Validate() error
+	Evaluate(ctx *Context) Result
+}
+
+// Windowed is implemented by evaluators that read Context.History. The
+// checker keeps enough history for the largest window of a condition tree.
+type Windowed interface {
+	HistoryWindow() (checks int, period time.Duration)
+}
+
+// Result is the outcome of an Evaluator. Expected and Actual are optional
+// and shown in the evaluation trace.
+type Result struct {
+	IsHealthy bool
+	Reason    string
+	Expected  string
+	Actual    string
+}
+
+// Config is the YAML value under the condition's key. *yaml.Node
+// implements it.
+type Config interface {
+	Decode(v interface{}) error
+}
+
+// Factory builds an Evaluator from its config.
+type Factory func(config Config) (Evaluator, error)
+
+var factories = registry.NewRegistry[Factory]()
+
+// reserved are the keys of the condition tree itself.
+var reserved = map[string]bool{
+	"and": true, "or": true, "not": true, "ref": true, "params": true, "severity": true,
+}
+
+// Register makes a condition type available under key. It is meant to be
+// called from init functions and panics when the key is already taken or
+// is one of the tree keys and, or, not, ref, params and severity.
+func Register(key string, factory Factory) {
+	if reserved[key] {
+		panic(fmt.Sprintf("condition type '%s' is reserved", key))
+	}
+	if _, ok := factories.Get(key); ok {
+		panic(fmt.Sprintf("condition type '%s' is already registered", key))
+	}
+	factories.Register(key, factory)
+}
+
+// Lookup returns the factory registered under key.
+func Lookup(key string) (Factory, bool) {
+	return factories.Get(key)
+}
@@ -0,0 +1,106 @@
+package conditionevaluator_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	conditionevaluator "healthy-api/evaluator"
+	"healthy-api/model"
+)
+
+// bodySize is an in-house condition type registered from outside package model.
+type bodySize struct {
+	Max int `yaml:"max"`
+}
+
+func (b *bodySize) Validate() error {
+	if b.Max <= 0 {
+		return fmt.Errorf("max must be positive")
+	}
+	return nil
+}
+
+func (b *bodySize) Evaluate(ctx *conditionevaluator.Context) conditionevaluator.Result {
+	res := conditionevaluator.Result{
+		IsHealthy: len(ctx.Body) <= b.Max,
+		Expected:  fmt.Sprintf("<= %d bytes", b.Max),
+		Actual:    fmt.Sprintf("%d bytes", len(ctx.Body)),
+	}
+	if !res.IsHealthy {
+		res.Reason = fmt.Sprintf("Body has %d bytes, more than %d", len(ctx.Body), b.Max)
+	}
+	return res
+}
+
+func init() {
+	conditionevaluator.Register("body_size", func(config conditionevaluator.Config) (conditionevaluator.Evaluator, error) {
+		b := &bodySize{}
+		return b, config.Decode(b)
+	})
+}
+
+func TestCustomConditionType(t *testing.T) {
+	src := `and:
+  - status_code: {code: 200}
+  - body_size: {max: 5}
+`
+	var cond model.Condition
+	if err := yaml.Unmarshal([]byte(src), &cond); err != nil {
+		t.Fatalf("failed to parse YAML: %v", err)
+	}
+	if err := cond.Validate("test"); err != nil {
+		t.Fatalf("Validation should pass, got: %v", err)
+	}
+
+	check := func(body string) model.EvaluationResult {
+		resp := &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(body))}
+		return cond.Evaluate(resp, []byte(body), time.Millisecond)
+	}
+	if res := check("tiny"); !res.IsHealthy {
+		t.Errorf("Should be healthy, but got: %s", res.Reason)
+	}
+	res := check("far too large")
+	if res.IsHealthy {
+		t.Fatal("Should fail for a large body")
+	}
+	leaf := res.Trace.Children[1]
+	if leaf.Type != "body_size" || leaf.Expected != "<= 5 bytes" || leaf.Actual != "13 bytes" {
+		t.Errorf("unexpected trace node: %+v", leaf)
+	}
+
+	var invalid model.Condition
+	if err := yaml.Unmarshal([]byte(`body_size: {max: 0}`), &invalid); err != nil {
+		t.Fatalf("failed to parse YAML: %v", err)
+	}
+	if err := invalid.Validate("test"); err == nil || !strings.Contains(err.Error(), "invalid body_size") {
+		t.Errorf("Validation should fail at load time, got: %v", err)
+	}
+}
+
+func TestRegisterTwicePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a taken key should panic")
+		}
+	}()
+	conditionevaluator.Register("regex", nil)
+}
+
+func TestRegisterReservedKeyPanics(t *testing.T) {
+	for _, key := range []string{"and", "or", "not", "ref", "params", "severity"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("registering '%s' should panic", key)
+				}
+			}()
+			conditionevaluator.Register(key, nil)
+		}()
+	}
+}
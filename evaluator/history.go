@@ -0,0 +1,66 @@
+package conditionevaluator
+
+import (
+	"sync"
+	"time"
+)
+
+// CheckSample is one finished check as remembered for window conditions.
+// Success is the outcome of the service condition with window conditions
+// left out, so a failing window never feeds back into its own input.
+type CheckSample struct {
+	Time       time.Time
+	Duration   time.Duration
+	StatusCode int // 0 when no response was received
+	Success    bool
+}
+
+// History is the rolling per-service record of recent checks. A sample is
+// kept while it is among the last MaxChecks samples or younger than MaxAge.
+type History struct {
+	MaxChecks int
+	MaxAge    time.Duration
+
+	mu      sync.Mutex
+	samples []CheckSample
+}
+
+func NewHistory(maxChecks int, maxAge time.Duration) *History {
+	return &History{MaxChecks: maxChecks, MaxAge: maxAge}
+}
+
+func (h *History) Add(s CheckSample) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.samples = append(h.samples, s)
+	keepFrom := len(h.samples) - h.MaxChecks
+	drop := 0
+	for i, sample := range h.samples {
+		if i >= keepFrom || s.Time.Sub(sample.Time) <= h.MaxAge {
+			break
+		}
+		drop++
+	}
+	if drop > 0 {
+		h.samples = append([]CheckSample(nil), h.samples[drop:]...)
+	}
+}
+
+// Window returns the samples of the last `checks` checks and/or of the last
+// `period`, oldest first. Zero values disable that bound.
+func (h *History) Window(checks int, period time.Duration, now time.Time) []CheckSample {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	samples := h.samples
+	if checks > 0 && len(samples) > checks {
+		samples = samples[len(samples)-checks:]
+	}
+	if period > 0 {
+		start := 0
+		for start < len(samples) && now.Sub(samples[start].Time) > period {
+			start++
+		}
+		samples = samples[start:]
+	}
+	return append([]CheckSample(nil), samples...)
+}
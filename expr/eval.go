@@ -0,0 +1,547 @@
+package expr
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Lookup lets a variable expose keyed values with its own key matching,
+// for example case-insensitive HTTP headers.
+type Lookup interface {
+	Lookup(key string) (any, bool)
+}
+
+func (n *literalNode) check(Env) (*Type, error) { return n.typ, nil }
+
+func (n *literalNode) eval(map[string]any) (any, error) { return n.val, nil }
+
+func (n *identNode) check(env Env) (*Type, error) {
+	t, ok := env[n.name]
+	if !ok {
+		return nil, errorf(n.pos, "unknown identifier '%s'", n.name)
+	}
+	return t, nil
+}
+
+func (n *identNode) eval(vars map[string]any) (any, error) {
+	v, ok := vars[n.name]
+	if !ok {
+		return nil, errorf(n.pos, "variable '%s' is not set", n.name)
+	}
+	return normalize(v), nil
+}
+
+func (n *unaryNode) check(env Env) (*Type, error) {
+	t, err := n.x.check(env)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "!":
+		if !t.is(KindBool) {
+			return nil, errorf(n.pos, "operator '!' needs a bool, got %s", t)
+		}
+		return Bool, nil
+	default:
+		if !t.is(KindNumber) && !t.is(KindDuration) {
+			return nil, errorf(n.pos, "operator '-' needs a number or duration, got %s", t)
+		}
+		return t, nil
+	}
+}
+
+func (n *unaryNode) eval(vars map[string]any) (any, error) {
+	v, err := n.x.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "!":
+		b, ok := v.(bool)
+		if !ok {
+			return nil, errorf(n.pos, "operator '!' needs a bool, got %s", typeName(v))
+		}
+		return !b, nil
+	default:
+		switch x := v.(type) {
+		case float64:
+			return -x, nil
+		case time.Duration:
+			return -x, nil
+		}
+		return nil, errorf(n.pos, "operator '-' needs a number or duration, got %s", typeName(v))
+	}
+}
+
+func (n *binaryNode) check(env Env) (*Type, error) {
+	l, err := n.l.check(env)
+	if err != nil {
+		return nil, err
+	}
+	r, err := n.r.check(env)
+	if err != nil {
+		return nil, err
+	}
+	mismatch := func() (*Type, error) {
+		return nil, errorf(n.pos, "operator '%s' cannot be applied to %s and %s", n.op, l, r)
+	}
+	switch n.op {
+	case "&&", "||":
+		if !l.is(KindBool) || !r.is(KindBool) {
+			return mismatch()
+		}
+		return Bool, nil
+	case "==", "!=":
+		if !compatible(l, r) {
+			return mismatch()
+		}
+		return Bool, nil
+	case "<", "<=", ">", ">=":
+		if !compatible(l, r) || !(l.is(KindNumber) || l.is(KindString) || l.is(KindDuration)) ||
+			!(r.is(KindNumber) || r.is(KindString) || r.is(KindDuration)) {
+			return mismatch()
+		}
+		return Bool, nil
+	case "in":
+		switch r.Kind {
+		case KindAny:
+		case KindList:
+			if !compatible(l, r.Elem) {
+				return mismatch()
+			}
+		case KindMap, KindString:
+			if !l.is(KindString) {
+				return mismatch()
+			}
+		default:
+			return mismatch()
+		}
+		return Bool, nil
+	case "+":
+		if l.Kind == KindAny || r.Kind == KindAny {
+			return Any, nil
+		}
+		if l.Kind == r.Kind && (l.Kind == KindNumber || l.Kind == KindString || l.Kind == KindDuration) {
+			return l, nil
+		}
+		return mismatch()
+	case "-":
+		if l.Kind == KindAny || r.Kind == KindAny {
+			return Any, nil
+		}
+		if l.Kind == r.Kind && (l.Kind == KindNumber || l.Kind == KindDuration) {
+			return l, nil
+		}
+		return mismatch()
+	case "*":
+		switch {
+		case l.Kind == KindAny || r.Kind == KindAny:
+			return Any, nil
+		case l.Kind == KindNumber && r.Kind == KindNumber:
+			return Number, nil
+		case l.Kind == KindDuration && r.Kind == KindNumber, l.Kind == KindNumber && r.Kind == KindDuration:
+			return Duration, nil
+		}
+		return mismatch()
+	case "/":
+		switch {
+		case l.Kind == KindAny || r.Kind == KindAny:
+			return Any, nil
+		case l.Kind == KindNumber && r.Kind == KindNumber:
+			return Number, nil
+		case l.Kind == KindDuration && r.Kind == KindNumber:
+			return Duration, nil
+		case l.Kind == KindDuration && r.Kind == KindDuration:
+			return Number, nil
+		}
+		return mismatch()
+	case "%":
+		if l.is(KindNumber) && r.is(KindNumber) {
+			return Number, nil
+		}
+		return mismatch()
+	}
+	return nil, errorf(n.pos, "unknown operator '%s'", n.op)
+}
+
+func (n *binaryNode) eval(vars map[string]any) (any, error) {
+	l, err := n.l.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "&&" || n.op == "||" {
+		lb, ok := l.(bool)
+		if !ok {
+			return nil, errorf(n.pos, "operator '%s' needs bools, got %s", n.op, typeName(l))
+		}
+		if (n.op == "&&" && !lb) || (n.op == "||" && lb) {
+			return lb, nil
+		}
+		r, err := n.r.eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		rb, ok := r.(bool)
+		if !ok {
+			return nil, errorf(n.pos, "operator '%s' needs bools, got %s", n.op, typeName(r))
+		}
+		return rb, nil
+	}
+	r, err := n.r.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	mismatch := func() (any, error) {
+		return nil, errorf(n.pos, "operator '%s' cannot be applied to %s and %s", n.op, typeName(l), typeName(r))
+	}
+	switch n.op {
+	case "==":
+		return equal(l, r), nil
+	case "!=":
+		return !equal(l, r), nil
+	case "<", "<=", ">", ">=":
+		c, ok := compare(l, r)
+		if !ok {
+			return mismatch()
+		}
+		switch n.op {
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		default:
+			return c >= 0, nil
+		}
+	case "in":
+		switch container := r.(type) {
+		case []any:
+			for _, item := range container {
+				if equal(l, item) {
+					return true, nil
+				}
+			}
+			return false, nil
+		case map[string]any:
+			key, ok := l.(string)
+			if !ok {
+				return mismatch()
+			}
+			_, found := container[key]
+			return found, nil
+		case Lookup:
+			key, ok := l.(string)
+			if !ok {
+				return mismatch()
+			}
+			_, found := container.Lookup(key)
+			return found, nil
+		case string:
+			sub, ok := l.(string)
+			if !ok {
+				return mismatch()
+			}
+			return strings.Contains(container, sub), nil
+		}
+		return mismatch()
+	}
+	switch a := l.(type) {
+	case float64:
+		switch b := r.(type) {
+		case float64:
+			switch n.op {
+			case "+":
+				return a + b, nil
+			case "-":
+				return a - b, nil
+			case "*":
+				return a * b, nil
+			case "/":
+				if b == 0 {
+					return nil, errorf(n.pos, "division by zero")
+				}
+				return a / b, nil
+			case "%":
+				if b == 0 {
+					return nil, errorf(n.pos, "division by zero")
+				}
+				return math.Mod(a, b), nil
+			}
+		case time.Duration:
+			if n.op == "*" {
+				return time.Duration(a * float64(b)), nil
+			}
+		}
+	case time.Duration:
+		switch b := r.(type) {
+		case time.Duration:
+			switch n.op {
+			case "+":
+				return a + b, nil
+			case "-":
+				return a - b, nil
+			case "/":
+				if b == 0 {
+					return nil, errorf(n.pos, "division by zero")
+				}
+				return float64(a) / float64(b), nil
+			}
+		case float64:
+			switch n.op {
+			case "*":
+				return time.Duration(float64(a) * b), nil
+			case "/":
+				if b == 0 {
+					return nil, errorf(n.pos, "division by zero")
+				}
+				return time.Duration(float64(a) / b), nil
+			}
+		}
+	case string:
+		if b, ok := r.(string); ok && n.op == "+" {
+			return a + b, nil
+		}
+	}
+	return mismatch()
+}
+
+func (n *memberNode) check(env Env) (*Type, error) {
+	t, err := n.x.check(env)
+	if err != nil {
+		return nil, err
+	}
+	switch t.Kind {
+	case KindAny:
+		return Any, nil
+	case KindMap:
+		if t.Fields == nil {
+			return t.Elem, nil
+		}
+		ft, ok := t.Fields[n.name]
+		if !ok {
+			return nil, errorf(n.pos, "unknown field '%s' on %s", n.name, t)
+		}
+		return ft, nil
+	}
+	return nil, errorf(n.pos, "cannot access field '%s' on %s", n.name, t)
+}
+
+func (n *memberNode) eval(vars map[string]any) (any, error) {
+	v, err := n.x.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	return lookupKey(n.pos, v, n.name)
+}
+
+func (n *indexNode) check(env Env) (*Type, error) {
+	t, err := n.x.check(env)
+	if err != nil {
+		return nil, err
+	}
+	it, err := n.idx.check(env)
+	if err != nil {
+		return nil, err
+	}
+	switch t.Kind {
+	case KindAny:
+		return Any, nil
+	case KindList:
+		if !it.is(KindNumber) {
+			return nil, errorf(n.pos, "list index must be a number, got %s", it)
+		}
+		return t.Elem, nil
+	case KindMap:
+		if !it.is(KindString) {
+			return nil, errorf(n.pos, "map key must be a string, got %s", it)
+		}
+		if t.Fields != nil {
+			return Any, nil
+		}
+		return t.Elem, nil
+	case KindString:
+		if !it.is(KindNumber) {
+			return nil, errorf(n.pos, "string index must be a number, got %s", it)
+		}
+		return String, nil
+	}
+	return nil, errorf(n.pos, "cannot index %s", t)
+}
+
+func (n *indexNode) eval(vars map[string]any) (any, error) {
+	v, err := n.x.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := n.idx.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	switch i := idx.(type) {
+	case string:
+		return lookupKey(n.pos, v, i)
+	case float64:
+		switch x := v.(type) {
+		case []any:
+			if i < 0 || int(i) >= len(x) || i != math.Trunc(i) {
+				return nil, errorf(n.pos, "index %v out of range (length %d)", i, len(x))
+			}
+			return normalize(x[int(i)]), nil
+		case string:
+			runes := []rune(x)
+			if i < 0 || int(i) >= len(runes) || i != math.Trunc(i) {
+				return nil, errorf(n.pos, "index %v out of range (length %d)", i, len(runes))
+			}
+			return string(runes[int(i)]), nil
+		}
+	}
+	return nil, errorf(n.pos, "cannot index %s with %s", typeName(v), typeName(idx))
+}
+
+func (n *listNode) check(env Env) (*Type, error) {
+	var elem *Type
+	for _, item := range n.items {
+		t, err := item.check(env)
+		if err != nil {
+			return nil, err
+		}
+		if elem == nil {
+			elem = t
+		} else {
+			elem = unify(elem, t)
+		}
+	}
+	if elem == nil {
+		elem = Any
+	}
+	return ListOf(elem), nil
+}
+
+func (n *listNode) eval(vars map[string]any) (any, error) {
+	items := make([]any, 0, len(n.items))
+	for _, item := range n.items {
+		v, err := item.eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, v)
+	}
+	return items, nil
+}
+
+// lookupKey returns nil for missing keys so optional JSON fields can be
+// compared against null.
+func lookupKey(pos int, v any, key string) (any, error) {
+	switch x := v.(type) {
+	case map[string]any:
+		return normalize(x[key]), nil
+	case Lookup:
+		val, _ := x.Lookup(key)
+		return normalize(val), nil
+	case nil:
+		return nil, errorf(pos, "cannot read '%s' of null", key)
+	}
+	return nil, errorf(pos, "cannot read '%s' of %s", key, typeName(v))
+}
+
+// normalize converts Go values handed in by callers to the small set of
+// runtime types the evaluator works with.
+func normalize(v any) any {
+	switch x := v.(type) {
+	case int:
+		return float64(x)
+	case int64:
+		return float64(x)
+	case int32:
+		return float64(x)
+	case uint16:
+		return float64(x)
+	case float32:
+		return float64(x)
+	case []string:
+		items := make([]any, len(x))
+		for i, s := range x {
+			items[i] = s
+		}
+		return items
+	case []int:
+		items := make([]any, len(x))
+		for i, n := range x {
+			items[i] = float64(n)
+		}
+		return items
+	case map[string]string:
+		m := make(map[string]any, len(x))
+		for k, s := range x {
+			m[k] = s
+		}
+		return m
+	}
+	return v
+}
+
+func equal(a, b any) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	t := reflect.TypeOf(a)
+	if t != reflect.TypeOf(b) {
+		return false
+	}
+	if !t.Comparable() {
+		return reflect.DeepEqual(a, b)
+	}
+	return a == b
+}
+
+func compare(a, b any) (int, bool) {
+	switch x := a.(type) {
+	case float64:
+		if y, ok := b.(float64); ok {
+			return cmp3(x < y, x > y), true
+		}
+	case string:
+		if y, ok := b.(string); ok {
+			return strings.Compare(x, y), true
+		}
+	case time.Duration:
+		if y, ok := b.(time.Duration); ok {
+			return cmp3(x < y, x > y), true
+		}
+	}
+	return 0, false
+}
+
+func cmp3(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	}
+	return 0
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case time.Duration:
+		return "duration"
+	case []any:
+		return "list"
+	case map[string]any, Lookup:
+		return "map"
+	}
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,80 @@
+// Package expr implements a small, side-effect free expression language used
+// by `expr:` conditions, e.g.
+//
+//	status in [200, 204] && json.queue.depth < 1000 && duration < 500ms
+//
+// Expressions are parsed and type-checked once by Compile and can then be
+// evaluated any number of times, concurrently, against a set of variables.
+package expr
+
+import "fmt"
+
+type Program struct {
+	source string
+	root   node
+	idents map[string]bool
+}
+
+// Compile parses src and type-checks it against env. The expression must
+// produce a bool.
+func Compile(src string, env Env) (*Program, error) {
+	root, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+	t, err := root.check(env)
+	if err != nil {
+		return nil, err
+	}
+	if !t.is(KindBool) {
+		return nil, &Error{Pos: -1, Msg: fmt.Sprintf("expression must produce a bool, got %s", t)}
+	}
+	p := &Program{source: src, root: root, idents: map[string]bool{}}
+	collectIdents(root, p.idents)
+	return p, nil
+}
+
+func (p *Program) String() string { return p.source }
+
+// Uses reports whether the expression references the variable name, so
+// callers can skip computing expensive variables such as parsed JSON.
+func (p *Program) Uses(name string) bool { return p.idents[name] }
+
+// Eval runs the program. Errors are returned for runtime type mismatches,
+// such as comparing a missing JSON field with a number.
+func (p *Program) Eval(vars map[string]any) (bool, error) {
+	v, err := p.root.eval(vars)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, &Error{Pos: -1, Msg: fmt.Sprintf("expression produced %s, not a bool", typeName(v))}
+	}
+	return b, nil
+}
+
+func collectIdents(n node, idents map[string]bool) {
+	switch x := n.(type) {
+	case *identNode:
+		idents[x.name] = true
+	case *unaryNode:
+		collectIdents(x.x, idents)
+	case *binaryNode:
+		collectIdents(x.l, idents)
+		collectIdents(x.r, idents)
+	case *memberNode:
+		collectIdents(x.x, idents)
+	case *indexNode:
+		collectIdents(x.x, idents)
+		collectIdents(x.idx, idents)
+	case *callNode:
+		for _, arg := range x.args {
+			collectIdents(arg, idents)
+		}
+	case *listNode:
+		for _, item := range x.items {
+			collectIdents(item, idents)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package expr_test
+
+import (
+	"healthy-api/expr"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testEnv = expr.Env{
+	"status":   expr.Number,
+	"body":     expr.String,
+	"headers":  expr.MapOf(expr.String),
+	"json":     expr.Any,
+	"duration": expr.Duration,
+	"tls":      expr.ObjectOf(map[string]*expr.Type{"enabled": expr.Bool, "version": expr.String}),
+}
+
+func testVars() map[string]any {
+	return map[string]any{
+		"status":   200,
+		"body":     `{"queue":{"depth":12},"version":"1.4.2"}`,
+		"headers":  map[string]string{"Content-Type": "application/json"},
+		"json":     map[string]any{"queue": map[string]any{"depth": 12.0}, "version": "1.4.2", "items": []any{"a", "b"}},
+		"duration": 120 * time.Millisecond,
+		"tls":      map[string]any{"enabled": true, "version": "TLS 1.3"},
+	}
+}
+
+func TestEval(t *testing.T) {
+	cases := []struct {
+		src  string
+		want bool
+	}{
+		{`status in [200, 204] && json.queue.depth < 1000 && duration < 500ms`, true},
+		{`status == 204 || duration > 1s`, false},
+		{`!(status >= 500)`, true},
+		{`contains(body, "queue") && startsWith(json.version, "1.")`, true},
+		{`matches(headers["Content-Type"], "^application/json")`, true},
+		{`"Content-Type" in headers && !("X-Debug" in headers)`, true},
+		{`len(json.items) == 2 && json.items[1] == "b"`, true},
+		{`json.missing == null`, true},
+		{`tls.enabled && tls.version == "TLS 1.3"`, true},
+		{`duration * 2 < 250ms && duration + 1s > 1s`, true},
+		{`number("42") % 5 == 2`, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.src, func(t *testing.T) {
+			p, err := expr.Compile(tc.src, testEnv)
+			if err != nil {
+				t.Fatalf("compile failed: %v", err)
+			}
+			got, err := p.Eval(testVars())
+			if err != nil {
+				t.Fatalf("eval failed: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	cases := map[string]string{
+		`stauts == 200`:           "unknown identifier 'stauts'",
+		`status == "200"`:         "cannot be applied to number and string",
+		`duration < 500`:          "cannot be applied to duration and number",
+		`status + 1`:              "must produce a bool",
+		`tls.expiry < 1h`:         "unknown field 'expiry'",
+		`matches(body, "(")`:      "invalid pattern",
+		`len(body, body) > 1`:     "takes 1 argument(s)",
+		`status == 200 &&`:        "unexpected end of expression",
+		`status in [200, 204`:     "expected ']'",
+		`duration < 5parsecs`:     "invalid duration literal",
+		`body == 'unterminated`:   "unterminated string",
+		`lower(status) == "x"`:    "argument 1 of lower() must be string",
+		`(status == 200`:          "expected ')'",
+		`status == 200 status`:    "unexpected 'status'",
+		`contains(body) || false`: "takes 2 argument(s)",
+	}
+	for src, want := range cases {
+		_, err := expr.Compile(src, testEnv)
+		if err == nil {
+			t.Errorf("%s: expected error containing %q", src, want)
+			continue
+		}
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: error %q does not contain %q", src, err, want)
+		}
+	}
+}
+
+func TestEvalRuntimeError(t *testing.T) {
+	p, err := expr.Compile(`json.queue.size.depth < 10`, testEnv)
+	if err != nil {
+		t.Fatalf("compile failed: %v", err)
+	}
+	if _, err := p.Eval(testVars()); err == nil {
+		t.Error("expected an error reading a field of null")
+	}
+}
@@ -0,0 +1,203 @@
+package expr
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type function struct {
+	params []*Type
+	result *Type
+	// prepare runs at check time and may return state passed to call,
+	// e.g. a regex compiled from a literal pattern.
+	prepare func(args []node) (any, error)
+	call    func(args []any, state any) (any, error)
+}
+
+var functions = map[string]*function{
+	"len": {
+		params: []*Type{Any},
+		result: Number,
+		call: func(args []any, _ any) (any, error) {
+			switch x := args[0].(type) {
+			case string:
+				return float64(utf8.RuneCountInString(x)), nil
+			case []any:
+				return float64(len(x)), nil
+			case map[string]any:
+				return float64(len(x)), nil
+			}
+			return nil, errorf(-1, "len() needs a string, list or map, got %s", typeName(args[0]))
+		},
+	},
+	"lower":      stringFunc(strings.ToLower),
+	"upper":      stringFunc(strings.ToUpper),
+	"trim":       stringFunc(strings.TrimSpace),
+	"contains":   predicateFunc(strings.Contains),
+	"startsWith": predicateFunc(strings.HasPrefix),
+	"endsWith":   predicateFunc(strings.HasSuffix),
+	"matches": {
+		params: []*Type{String, String},
+		result: Bool,
+		prepare: func(args []node) (any, error) {
+			lit, ok := args[1].(*literalNode)
+			if !ok {
+				return nil, nil
+			}
+			re, err := regexp.Compile(lit.val.(string))
+			if err != nil {
+				return nil, errorf(lit.pos, "invalid pattern: %v", err)
+			}
+			return re, nil
+		},
+		call: func(args []any, state any) (any, error) {
+			s, ok1 := args[0].(string)
+			pattern, ok2 := args[1].(string)
+			if !ok1 || !ok2 {
+				return nil, errorf(-1, "matches() needs strings, got %s and %s", typeName(args[0]), typeName(args[1]))
+			}
+			re, _ := state.(*regexp.Regexp)
+			if re == nil {
+				var err error
+				if re, err = regexp.Compile(pattern); err != nil {
+					return nil, errorf(-1, "invalid pattern: %v", err)
+				}
+			}
+			return re.MatchString(s), nil
+		},
+	},
+	"has": {
+		params: []*Type{Any, String},
+		result: Bool,
+		call: func(args []any, _ any) (any, error) {
+			key, ok := args[1].(string)
+			if !ok {
+				return nil, errorf(-1, "has() needs a string key, got %s", typeName(args[1]))
+			}
+			switch x := args[0].(type) {
+			case map[string]any:
+				_, found := x[key]
+				return found, nil
+			case Lookup:
+				_, found := x.Lookup(key)
+				return found, nil
+			case nil:
+				return false, nil
+			}
+			return nil, errorf(-1, "has() needs a map, got %s", typeName(args[0]))
+		},
+	},
+	"number": {
+		params: []*Type{Any},
+		result: Number,
+		call: func(args []any, _ any) (any, error) {
+			switch x := args[0].(type) {
+			case float64:
+				return x, nil
+			case string:
+				n, err := strconv.ParseFloat(strings.TrimSpace(x), 64)
+				if err != nil {
+					return nil, errorf(-1, "cannot convert '%s' to a number", x)
+				}
+				return n, nil
+			case time.Duration:
+				return float64(x), nil
+			}
+			return nil, errorf(-1, "cannot convert %s to a number", typeName(args[0]))
+		},
+	},
+	"duration": {
+		params: []*Type{String},
+		result: Duration,
+		call: func(args []any, _ any) (any, error) {
+			s, ok := args[0].(string)
+			if !ok {
+				return nil, errorf(-1, "duration() needs a string, got %s", typeName(args[0]))
+			}
+			d, err := time.ParseDuration(strings.TrimSpace(s))
+			if err != nil {
+				return nil, errorf(-1, "cannot convert '%s' to a duration", s)
+			}
+			return d, nil
+		},
+	},
+}
+
+func stringFunc(f func(string) string) *function {
+	return &function{
+		params: []*Type{String},
+		result: String,
+		call: func(args []any, _ any) (any, error) {
+			s, ok := args[0].(string)
+			if !ok {
+				return nil, errorf(-1, "expected a string, got %s", typeName(args[0]))
+			}
+			return f(s), nil
+		},
+	}
+}
+
+func predicateFunc(f func(string, string) bool) *function {
+	return &function{
+		params: []*Type{String, String},
+		result: Bool,
+		call: func(args []any, _ any) (any, error) {
+			a, ok1 := args[0].(string)
+			b, ok2 := args[1].(string)
+			if !ok1 || !ok2 {
+				return nil, errorf(-1, "expected strings, got %s and %s", typeName(args[0]), typeName(args[1]))
+			}
+			return f(a, b), nil
+		},
+	}
+}
+
+func (n *callNode) check(env Env) (*Type, error) {
+	fn, ok := functions[n.name]
+	if !ok {
+		return nil, errorf(n.pos, "unknown function '%s'", n.name)
+	}
+	if len(n.args) != len(fn.params) {
+		return nil, errorf(n.pos, "%s() takes %d argument(s), got %d", n.name, len(fn.params), len(n.args))
+	}
+	for i, arg := range n.args {
+		t, err := arg.check(env)
+		if err != nil {
+			return nil, err
+		}
+		if fn.params[i].Kind != KindAny && !t.is(fn.params[i].Kind) {
+			return nil, errorf(arg.position(), "argument %d of %s() must be %s, got %s", i+1, n.name, fn.params[i], t)
+		}
+	}
+	if fn.prepare != nil {
+		state, err := fn.prepare(n.args)
+		if err != nil {
+			return nil, err
+		}
+		n.state = state
+	}
+	n.fn = fn
+	return fn.result, nil
+}
+
+func (n *callNode) eval(vars map[string]any) (any, error) {
+	if n.fn == nil {
+		return nil, errorf(n.pos, "function '%s' was not type-checked", n.name)
+	}
+	args := make([]any, len(n.args))
+	for i, arg := range n.args {
+		v, err := arg.eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	v, err := n.fn.call(args, n.state)
+	if e, ok := err.(*Error); ok && e.Pos < 0 {
+		e.Pos = n.pos
+	}
+	return v, err
+}
@@ -0,0 +1,139 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokDuration
+	tokString
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+	num  float64
+	dur  time.Duration
+}
+
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "+", "-", "*", "/", "%", "(", ")", "[", "]", ",", "."}
+
+func lex(src string) ([]token, error) {
+	var tokens []token
+	runes := []rune(src)
+	i := 0
+	for i < len(runes) {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			if i < len(runes) && (unicode.IsLetter(runes[i]) || runes[i] == 'µ') {
+				// Duration literals such as 500ms or 1m30s.
+				for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '.' || runes[i] == 'µ') {
+					i++
+				}
+				text := string(runes[start:i])
+				d, err := time.ParseDuration(text)
+				if err != nil {
+					return nil, errorf(start, "invalid duration literal '%s'", text)
+				}
+				tokens = append(tokens, token{kind: tokDuration, text: text, pos: start, dur: d})
+				continue
+			}
+			text := string(runes[start:i])
+			n, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				return nil, errorf(start, "invalid number '%s'", text)
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: text, pos: start, num: n})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: string(runes[start:i]), pos: start})
+		case r == '"' || r == '\'':
+			start := i
+			quote := r
+			var sb strings.Builder
+			i++
+			closed := false
+			for i < len(runes) {
+				c := runes[i]
+				if c == '\\' && i+1 < len(runes) {
+					i++
+					switch runes[i] {
+					case 'n':
+						sb.WriteRune('\n')
+					case 't':
+						sb.WriteRune('\t')
+					default:
+						sb.WriteRune(runes[i])
+					}
+					i++
+					continue
+				}
+				if c == quote {
+					closed = true
+					i++
+					break
+				}
+				sb.WriteRune(c)
+				i++
+			}
+			if !closed {
+				return nil, errorf(start, "unterminated string")
+			}
+			tokens = append(tokens, token{kind: tokString, text: sb.String(), pos: start})
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(string(runes[i:min(i+len(op), len(runes))]), op) {
+					tokens = append(tokens, token{kind: tokOp, text: op, pos: i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, errorf(i, "unexpected character '%c'", r)
+			}
+		}
+	}
+	tokens = append(tokens, token{kind: tokEOF, pos: len(runes)})
+	return tokens, nil
+}
+
+// Error is a parse, type or evaluation error. Pos is the rune offset in the
+// source expression, or -1 when unknown.
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	if e.Pos < 0 {
+		return e.Msg
+	}
+	return fmt.Sprintf("column %d: %s", e.Pos+1, e.Msg)
+}
+
+func errorf(pos int, format string, args ...any) *Error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,259 @@
+package expr
+
+type node interface {
+	position() int
+	check(env Env) (*Type, error)
+	eval(vars map[string]any) (any, error)
+}
+
+type (
+	literalNode struct {
+		pos int
+		val any
+		typ *Type
+	}
+	identNode struct {
+		pos  int
+		name string
+	}
+	unaryNode struct {
+		pos int
+		op  string
+		x   node
+	}
+	binaryNode struct {
+		pos  int
+		op   string
+		l, r node
+	}
+	memberNode struct {
+		pos  int
+		x    node
+		name string
+	}
+	indexNode struct {
+		pos int
+		x   node
+		idx node
+	}
+	callNode struct {
+		pos  int
+		name string
+		args []node
+		fn   *function
+		// state holds per-call data prepared at check time, such as a compiled regex.
+		state any
+	}
+	listNode struct {
+		pos   int
+		items []node
+	}
+)
+
+func (n *literalNode) position() int { return n.pos }
+func (n *identNode) position() int   { return n.pos }
+func (n *unaryNode) position() int   { return n.pos }
+func (n *binaryNode) position() int  { return n.pos }
+func (n *memberNode) position() int  { return n.pos }
+func (n *indexNode) position() int   { return n.pos }
+func (n *callNode) position() int    { return n.pos }
+func (n *listNode) position() int    { return n.pos }
+
+type parser struct {
+	tokens []token
+	i      int
+}
+
+// binary operator precedence, lowest first.
+var precedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<", "<=", ">", ">=", "in"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func parse(src string) (node, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	n, err := p.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, errorf(t.pos, "unexpected '%s'", t.text)
+	}
+	return n, nil
+}
+
+func (p *parser) peek() token { return p.tokens[p.i] }
+
+func (p *parser) next() token {
+	t := p.tokens[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) isOp(t token, ops ...string) bool {
+	if t.kind != tokOp && !(t.kind == tokIdent && t.text == "in") {
+		return false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) expect(op string) (token, error) {
+	t := p.next()
+	if t.kind != tokOp || t.text != op {
+		if t.kind == tokEOF {
+			return t, errorf(t.pos, "expected '%s' but reached end of expression", op)
+		}
+		return t, errorf(t.pos, "expected '%s' but found '%s'", op, t.text)
+	}
+	return t, nil
+}
+
+func (p *parser) parseBinary(level int) (node, error) {
+	if level == len(precedence) {
+		return p.parseUnary()
+	}
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp(p.peek(), precedence[level]...) {
+		op := p.next()
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{pos: op.pos, op: op.text, l: left, r: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if t := p.peek(); p.isOp(t, "!", "-") {
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{pos: t.pos, op: t.text, x: x}, nil
+	}
+	return p.parsePostfix()
+}
+
+func (p *parser) parsePostfix() (node, error) {
+	x, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		switch {
+		case p.isOp(t, "."):
+			p.next()
+			name := p.next()
+			if name.kind != tokIdent {
+				return nil, errorf(name.pos, "expected field name after '.'")
+			}
+			x = &memberNode{pos: name.pos, x: x, name: name.text}
+		case p.isOp(t, "["):
+			p.next()
+			idx, err := p.parseBinary(0)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			x = &indexNode{pos: t.pos, x: x, idx: idx}
+		default:
+			return x, nil
+		}
+	}
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		return &literalNode{pos: t.pos, val: t.num, typ: Number}, nil
+	case tokDuration:
+		return &literalNode{pos: t.pos, val: t.dur, typ: Duration}, nil
+	case tokString:
+		return &literalNode{pos: t.pos, val: t.text, typ: String}, nil
+	case tokIdent:
+		switch t.text {
+		case "true", "false":
+			return &literalNode{pos: t.pos, val: t.text == "true", typ: Bool}, nil
+		case "null":
+			return &literalNode{pos: t.pos, val: nil, typ: Null}, nil
+		case "in":
+			return nil, errorf(t.pos, "unexpected 'in'")
+		}
+		if p.isOp(p.peek(), "(") {
+			p.next()
+			args, err := p.parseList(")")
+			if err != nil {
+				return nil, err
+			}
+			return &callNode{pos: t.pos, name: t.text, args: args}, nil
+		}
+		return &identNode{pos: t.pos, name: t.text}, nil
+	case tokOp:
+		switch t.text {
+		case "(":
+			x, err := p.parseBinary(0)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return x, nil
+		case "[":
+			items, err := p.parseList("]")
+			if err != nil {
+				return nil, err
+			}
+			return &listNode{pos: t.pos, items: items}, nil
+		}
+		return nil, errorf(t.pos, "unexpected '%s'", t.text)
+	}
+	return nil, errorf(t.pos, "unexpected end of expression")
+}
+
+func (p *parser) parseList(closing string) ([]node, error) {
+	var items []node
+	if p.isOp(p.peek(), closing) {
+		p.next()
+		return items, nil
+	}
+	for {
+		item, err := p.parseBinary(0)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		if p.isOp(p.peek(), ",") {
+			p.next()
+			continue
+		}
+		if _, err := p.expect(closing); err != nil {
+			return nil, err
+		}
+		return items, nil
+	}
+}
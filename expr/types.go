@@ -0,0 +1,92 @@
+package expr
+
+import (
+	"sort"
+	"strings"
+)
+
+type Kind int
+
+const (
+	KindAny Kind = iota
+	KindBool
+	KindNumber
+	KindString
+	KindDuration
+	KindList
+	KindMap
+	KindNull
+)
+
+// Type is the static type of an expression. Lists and maps carry their
+// element type; objects are maps with a fixed set of Fields.
+type Type struct {
+	Kind   Kind
+	Elem   *Type
+	Fields map[string]*Type
+}
+
+var (
+	Any      = &Type{Kind: KindAny}
+	Bool     = &Type{Kind: KindBool}
+	Number   = &Type{Kind: KindNumber}
+	String   = &Type{Kind: KindString}
+	Duration = &Type{Kind: KindDuration}
+	Null     = &Type{Kind: KindNull}
+)
+
+func ListOf(elem *Type) *Type { return &Type{Kind: KindList, Elem: elem} }
+
+func MapOf(elem *Type) *Type { return &Type{Kind: KindMap, Elem: elem} }
+
+func ObjectOf(fields map[string]*Type) *Type { return &Type{Kind: KindMap, Fields: fields} }
+
+// Env declares the variables an expression may reference.
+type Env map[string]*Type
+
+func (t *Type) String() string {
+	switch t.Kind {
+	case KindBool:
+		return "bool"
+	case KindNumber:
+		return "number"
+	case KindString:
+		return "string"
+	case KindDuration:
+		return "duration"
+	case KindNull:
+		return "null"
+	case KindList:
+		return "list<" + t.Elem.String() + ">"
+	case KindMap:
+		if t.Fields != nil {
+			names := make([]string, 0, len(t.Fields))
+			for name := range t.Fields {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return "{" + strings.Join(names, ", ") + "}"
+		}
+		return "map<" + t.Elem.String() + ">"
+	}
+	return "any"
+}
+
+// is reports whether t is statically known to be of kind k or is dynamic.
+func (t *Type) is(k Kind) bool {
+	return t.Kind == k || t.Kind == KindAny
+}
+
+func compatible(a, b *Type) bool {
+	if a.Kind == KindAny || b.Kind == KindAny || a.Kind == KindNull || b.Kind == KindNull {
+		return true
+	}
+	return a.Kind == b.Kind
+}
+
+func unify(a, b *Type) *Type {
+	if a.Kind == b.Kind && a.Kind != KindList && a.Kind != KindMap {
+		return a
+	}
+	return Any
+}
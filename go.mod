@@ -2,4 +2,4 @@ module healthy-api
 
 go 1.24.1
 
-require gopkg.in/yaml.v3 v3.0.1 // indirect
+require gopkg.in/yaml.v3 v3.0.1
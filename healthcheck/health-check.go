@@ -17,6 +17,13 @@ type HealthChecker struct {
 	ConditionRegistry *registry.Registry[model.Condition]
 	Client            *http.Client
 	Logger            *slog.Logger
+
+	history   *model.History
+	baselines *model.BaselineStore
+	// alerted holds the indexes of the targets notified since the service
+	// was last healthy; downSince is the first failed check of that outage.
+	alerted   map[int]bool
+	downSince time.Time
 }
 
 // func (h *HealthChecker) Start() {
@@ -184,96 +191,211 @@ type HealthChecker struct {
 func (h *HealthChecker) Start() {
 	h.Logger.Info("checker_started", "service", h.Service.Name)
 	failureCount := 0
+	h.prepare()
 
 	for {
 		start := time.Now()
-		request, err := http.NewRequest("GET", h.Service.URL, nil)
-		
-		var resp *http.Response
-		var bodyData []byte
-		
-		evaluationRes := model.EvaluationResult{
-			IsHealthy: false,
-			Reason:    "Unknown error",
-		}
-
-		if h.Service.UserAgent != "" {
-			request.Header.Set("User-Agent", h.Service.UserAgent)
-		} else {
-			h.Logger.Warn("using_default_user_agent")
-			request.Header.Set("User-Agent", "HealthyAPI(M.A)/1.0")
-		}
-
-		if err == nil {
-			resp, err = h.Client.Do(request)
-		}
-		
-		requestDuration := time.Since(start)
-		sCode := 0
-
-		if err != nil {
-			evaluationRes.Reason = fmt.Sprintf("Network/Connection Error: %v", err)
-		} else if resp != nil {
-			sCode = resp.StatusCode
-
-			bodyData, _ = io.ReadAll(resp.Body)
-			resp.Body.Close() 
-			
-			cond, ok := h.ConditionRegistry.Get(h.Service.ConditionName)
-			if ok {
-				evaluationRes = cond.Evaluate(resp, bodyData, requestDuration)
-			} else {
-				evaluationRes.Reason = "Condition registry not found"
-			}
-		}
+		evaluationRes, sCode, requestDuration, timing := h.check(start)
 
 		if !evaluationRes.IsHealthy {
 			failureCount++
-			
-			if resp != nil {
-				sCode = resp.StatusCode
+			if h.downSince.IsZero() {
+				h.downSince = start
 			}
 			
 			h.Logger.Warn("health_check_failed", 
 				"service", h.Service.Name, 
+				"state", evaluationRes.State(),
 				"attempt", failureCount, 
 				"threshold", h.Service.Threshold, 
 				"status", sCode, 
 				"duration", requestDuration, 
-				"reason", evaluationRes.Reason)
+				"timing", timing.String(),
+				"reason", evaluationRes.Reason,
+				"trace", evaluationRes.Trace.JSON())
 
 			if failureCount >= h.Service.Threshold {
 				h.Logger.Error("threshold_reached", "service", h.Service.Name, "action", "sending_notifications")
 				
-				for _, target := range h.Service.Targets {
+				for i, target := range h.Service.Targets {
+					if !target.Accepts(evaluationRes.Severity) {
+						continue
+					}
 					if n, ok := h.NotifierRegistry.Get(target.NotifierID); ok {
+						if h.alerted == nil {
+							h.alerted = make(map[int]bool)
+						}
+						h.alerted[i] = true
 						_ = n.Notify(model.Notification{
 							ServiceName:  h.Service.Name,
+							URL:          h.Service.URL,
 							Recipients:   target.Recipients,
 							Reason:       evaluationRes.Reason, 
 							StatusCode:   sCode,                    
 							ResponseTime: requestDuration.Round(time.Millisecond).String(),
+							Timing:       timing,
+							Trace:        evaluationRes.Trace,
+							State:        evaluationRes.State(),
+							Severity:     evaluationRes.Severity,
 						})
 					}
 				}
 				
-				time.Sleep(time.Duration(h.Service.SleepOnFail) * time.Second)
+				time.Sleep(h.Service.FailInterval())
 				failureCount = 0 
 			} else {
-				time.Sleep(time.Duration(h.Service.CheckPeriod) * time.Second)
+				time.Sleep(h.Service.CheckInterval())
 			}
 		} else {
 			if failureCount > 0 {
 				h.Logger.Info("service_recovery", "service", h.Service.Name, "after_failures", failureCount)
 			}
+			if len(h.alerted) > 0 {
+				h.notifyRecovery(sCode, requestDuration, timing, start)
+			}
 			failureCount = 0
+			h.downSince = time.Time{}
 			
-			h.Logger.Info("health_check_success", "service", h.Service.Name, "duration", requestDuration,"status_code",sCode)
+			h.Logger.Info("health_check_success", "service", h.Service.Name, "duration", requestDuration,"status_code",sCode, "timing", timing.String())
 			
-			time.Sleep(time.Duration(h.Service.CheckPeriod) * time.Second)
+			time.Sleep(h.Service.CheckInterval())
 		}
 	}
 }
+// prepare sets up the state kept between checks. The history is created
+// here, not on the first response, so that a service unreachable from the
+// start has its network errors counted too.
+func (h *HealthChecker) prepare() {
+	h.baselines = model.NewBaselineStore()
+	if cond, ok := h.condition(); ok {
+		if checks, period, used := cond.HistoryWindow(); used {
+			h.history = model.NewHistory(checks, period)
+		}
+	}
+}
+
+// check sends one request and evaluates the service's condition.
+func (h *HealthChecker) check(start time.Time) (evaluationRes model.EvaluationResult, sCode int, requestDuration time.Duration, timing model.Timing) {
+	request, err := http.NewRequest("GET", h.Service.URL, nil)
+	
+	var resp *http.Response
+	var bodyData []byte
+	
+	evaluationRes = model.EvaluationResult{
+		IsHealthy: false,
+		Reason:    "Unknown error",
+		Severity:  model.SeverityCritical,
+	}
+
+	if h.Service.UserAgent != "" {
+		request.Header.Set("User-Agent", h.Service.UserAgent)
+	} else {
+		h.Logger.Warn("using_default_user_agent")
+		request.Header.Set("User-Agent", "HealthyAPI(M.A)/1.0")
+	}
+
+	phases := &phaseRecorder{}
+	redirects := &redirectRecorder{}
+	if err == nil {
+		resp, err = redirects.client(h.Client).Do(phases.trace(request))
+	}
+	
+	requestDuration = time.Since(start)
+
+	if err != nil {
+		timing = phases.finish(time.Now())
+		evaluationRes.Reason = fmt.Sprintf("Network/Connection Error: %v", err)
+		if h.history != nil {
+			h.history.Add(model.CheckSample{Time: start, Duration: requestDuration})
+		}
+	} else if resp != nil {
+		sCode = resp.StatusCode
+
+		bodyData, _ = io.ReadAll(resp.Body)
+		resp.Body.Close() 
+		timing = phases.finish(time.Now())
+		
+		cond, ok := h.condition()
+		if ok {
+			evaluationRes = h.evaluate(&cond, &model.ResponseContext{
+				Response:  resp,
+				Body:      bodyData,
+				Duration:  requestDuration,
+				Timing:    timing,
+				Baselines: h.baselines,
+				Redirects: redirects.chain(),
+			}, start)
+			h.baselines.Commit()
+		} else {
+			evaluationRes.Reason = fmt.Sprintf("Condition '%s' not found in registry", h.Service.ConditionName)
+		}
+	}
+	return evaluationRes, sCode, requestDuration, timing
+}
+
+// notifyRecovery tells the targets that received an alert that the service
+// is UP again. Only notifiers implementing notifier.Resolver are told.
+func (h *HealthChecker) notifyRecovery(statusCode int, duration time.Duration, timing model.Timing, now time.Time) {
+	reason := "Service is healthy again"
+	if !h.downSince.IsZero() {
+		reason = fmt.Sprintf("Service is healthy again after %s", now.Sub(h.downSince).Round(time.Second))
+	}
+	for i, target := range h.Service.Targets {
+		if !h.alerted[i] {
+			continue
+		}
+		n, ok := h.NotifierRegistry.Get(target.NotifierID)
+		if !ok {
+			continue
+		}
+		resolver, ok := n.(notifier.Resolver)
+		if !ok {
+			continue
+		}
+		err := resolver.Resolve(model.Notification{
+			ServiceName:  h.Service.Name,
+			URL:          h.Service.URL,
+			Recipients:   target.Recipients,
+			Reason:       reason,
+			StatusCode:   statusCode,
+			ResponseTime: duration.Round(time.Millisecond).String(),
+			Timing:       timing,
+			State:        model.StateUp,
+		})
+		if err != nil {
+			h.Logger.Error("recovery_notification_failed", "service", h.Service.Name, "notifier", n.GetName(), "error", err)
+		}
+	}
+	h.alerted = nil
+}
+
+// condition returns the service's own condition, or the named one from the
+// registry when the service uses condition_id.
+func (h *HealthChecker) condition() (model.Condition, bool) {
+	if h.Service.Condition != nil {
+		return *h.Service.Condition, true
+	}
+	return h.ConditionRegistry.Get(h.Service.ConditionName)
+}
+
+// evaluate runs the condition. Conditions with window nodes are run twice:
+// first without history to decide whether this check counts as a success
+// for the rolling history, then with the updated history.
+func (h *HealthChecker) evaluate(cond *model.Condition, rc *model.ResponseContext, start time.Time) model.EvaluationResult {
+	if _, _, used := cond.HistoryWindow(); !used || h.history == nil {
+		return cond.EvaluateContext(rc)
+	}
+	base := cond.EvaluateContext(rc)
+	h.history.Add(model.CheckSample{
+		Time:       start,
+		Duration:   rc.Duration,
+		StatusCode: rc.Response.StatusCode,
+		Success:    base.State() != model.StateDown,
+	})
+	rc.History = h.history
+	return cond.EvaluateContext(rc)
+}
+
 func (h *HealthChecker) StartInBackground() {
 	go h.Start()
 }
@@ -0,0 +1,66 @@
+package healthcheck
+
+import (
+	"healthy-api/model"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestHistoryRecordsNetworkErrorsFromStart(t *testing.T) {
+	var cond model.Condition
+	err := yaml.Unmarshal([]byte(`
+window:
+  metric: error_count
+  operator: "<="
+  value: "1"
+  checks: 10
+`), &cond)
+	if err != nil {
+		t.Fatalf("failed to decode condition: %v", err)
+	}
+	if err := cond.Validate("test"); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+
+	// A port nothing listens on.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	unreachable := "http://" + ln.Addr().String()
+	ln.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	h := &HealthChecker{
+		Service: model.Service{Name: "api", URL: unreachable, Condition: &cond},
+		Client:  &http.Client{Timeout: 5 * time.Second},
+		Logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	h.prepare()
+	for range 3 {
+		if res, _, _, _ := h.check(time.Now()); res.IsHealthy || !strings.Contains(res.Reason, "Network/Connection Error") {
+			t.Fatalf("expected a network error, got %+v", res)
+		}
+	}
+
+	h.Service.URL = server.URL
+	res, status, _, _ := h.check(time.Now())
+	if status != 200 {
+		t.Fatalf("expected the server to answer, got status %d", status)
+	}
+	if res.IsHealthy {
+		t.Errorf("the window should count the 3 network errors, got healthy: %s", res.Reason)
+	}
+	if n := len(h.history.Window(10, 0, time.Now())); n != 4 {
+		t.Errorf("expected 4 samples in the history, got %d", n)
+	}
+}
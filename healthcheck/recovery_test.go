@@ -0,0 +1,80 @@
+package healthcheck
+
+import (
+	"healthy-api/model"
+	"healthy-api/notifier"
+	"healthy-api/registry"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+type recordingNotifier struct {
+	notified []model.Notification
+}
+
+func (r *recordingNotifier) Notify(n model.Notification) error {
+	r.notified = append(r.notified, n)
+	return nil
+}
+
+func (r *recordingNotifier) GetName() string { return "recording" }
+
+type recordingResolver struct {
+	recordingNotifier
+	resolved []model.Notification
+}
+
+func (r *recordingResolver) Resolve(n model.Notification) error {
+	r.resolved = append(r.resolved, n)
+	return nil
+}
+
+func TestNotifyRecovery(t *testing.T) {
+	notifiers := registry.NewRegistry[notifier.Notifier]()
+	alerted := &recordingResolver{}
+	quiet := &recordingResolver{}
+	plain := &recordingNotifier{}
+	notifiers.Register("alerted", alerted)
+	notifiers.Register("quiet", quiet)
+	notifiers.Register("plain", plain)
+
+	start := time.Now()
+	h := &HealthChecker{
+		Service: model.Service{
+			Name: "api",
+			Targets: []model.Target{
+				{NotifierID: "alerted", Recipients: []string{"ops"}},
+				{NotifierID: "quiet"},
+				{NotifierID: "plain"},
+			},
+		},
+		NotifierRegistry: notifiers,
+		Logger:           slog.New(slog.NewTextHandler(io.Discard, nil)),
+		alerted:          map[int]bool{0: true, 2: true},
+		downSince:        start.Add(-90 * time.Second),
+	}
+	h.notifyRecovery(200, 120*time.Millisecond, model.Timing{}, start)
+
+	if len(alerted.resolved) != 1 {
+		t.Fatalf("expected one recovery, got %d", len(alerted.resolved))
+	}
+	n := alerted.resolved[0]
+	if n.State != model.StateUp || n.ServiceName != "api" || n.Recipients[0] != "ops" || n.StatusCode != 200 {
+		t.Errorf("unexpected recovery notification: %+v", n)
+	}
+	if !strings.Contains(n.Reason, "1m30s") {
+		t.Errorf("reason should contain the outage duration: %q", n.Reason)
+	}
+	if len(quiet.resolved) != 0 {
+		t.Error("targets that were not alerted should not get a recovery")
+	}
+	if len(plain.notified) != 0 {
+		t.Error("notifiers without Resolve should not be told about recoveries")
+	}
+	if h.alerted != nil {
+		t.Error("alerted targets should be cleared")
+	}
+}
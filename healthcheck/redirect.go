@@ -0,0 +1,48 @@
+package healthcheck
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+
+	"healthy-api/model"
+)
+
+// maxRedirects matches the limit of the default http.Client policy.
+const maxRedirects = 10
+
+// redirectRecorder records every hop the client follows.
+type redirectRecorder struct {
+	mu   sync.Mutex
+	hops []model.RedirectHop
+}
+
+// client returns a copy of c whose CheckRedirect records hops before
+// applying c's own policy, or the default limit of 10 redirects.
+func (r *redirectRecorder) client(c *http.Client) *http.Client {
+	copied := *c
+	next := c.CheckRedirect
+	copied.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		hop := model.RedirectHop{From: via[len(via)-1].URL.String(), To: req.URL.String()}
+		if req.Response != nil {
+			hop.StatusCode = req.Response.StatusCode
+		}
+		r.mu.Lock()
+		r.hops = append(r.hops, hop)
+		r.mu.Unlock()
+		if next != nil {
+			return next(req, via)
+		}
+		if len(via) >= maxRedirects {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	return &copied
+}
+
+func (r *redirectRecorder) chain() []model.RedirectHop {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]model.RedirectHop(nil), r.hops...)
+}
@@ -0,0 +1,51 @@
+package healthcheck
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRedirectRecorder(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/final", http.StatusFound)
+	})
+	mux.HandleFunc("/final", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	recorder := &redirectRecorder{}
+	resp, err := recorder.client(server.Client()).Get(server.URL + "/old")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	hops := recorder.chain()
+	if len(hops) != 2 {
+		t.Fatalf("expected 2 hops, got %+v", hops)
+	}
+	if hops[0].StatusCode != 301 || hops[0].From != server.URL+"/old" || hops[0].To != server.URL+"/new" {
+		t.Errorf("unexpected first hop: %+v", hops[0])
+	}
+	if hops[1].StatusCode != 302 || hops[1].To != server.URL+"/final" {
+		t.Errorf("unexpected second hop: %+v", hops[1])
+	}
+
+	loop := &redirectRecorder{}
+	if _, err := loop.client(server.Client()).Get(server.URL + "/loop"); err == nil {
+		t.Error("a redirect loop should stop with an error")
+	}
+	if n := len(loop.chain()); n != maxRedirects {
+		t.Errorf("expected %d recorded hops, got %d", maxRedirects, n)
+	}
+}
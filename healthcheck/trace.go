@@ -0,0 +1,88 @@
+package healthcheck
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"healthy-api/model"
+)
+
+// phaseRecorder collects request phases from httptrace callbacks. Phases
+// are summed, so redirects that open new connections add up.
+type phaseRecorder struct {
+	mu          sync.Mutex
+	timing      model.Timing
+	dnsStart    time.Time
+	connStart   time.Time
+	tlsStart    time.Time
+	wroteAt     time.Time
+	firstByteAt time.Time
+}
+
+func (p *phaseRecorder) trace(req *http.Request) *http.Request {
+	t := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			p.mu.Lock()
+			p.dnsStart = time.Now()
+			p.mu.Unlock()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			p.mu.Lock()
+			if !p.dnsStart.IsZero() {
+				p.timing.DNS += time.Since(p.dnsStart)
+			}
+			p.mu.Unlock()
+		},
+		ConnectStart: func(string, string) {
+			p.mu.Lock()
+			p.connStart = time.Now()
+			p.mu.Unlock()
+		},
+		ConnectDone: func(string, string, error) {
+			p.mu.Lock()
+			if !p.connStart.IsZero() {
+				p.timing.Connect += time.Since(p.connStart)
+			}
+			p.mu.Unlock()
+		},
+		TLSHandshakeStart: func() {
+			p.mu.Lock()
+			p.tlsStart = time.Now()
+			p.mu.Unlock()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			p.mu.Lock()
+			if !p.tlsStart.IsZero() {
+				p.timing.TLS += time.Since(p.tlsStart)
+			}
+			p.mu.Unlock()
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			p.mu.Lock()
+			p.wroteAt = time.Now()
+			p.mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			p.mu.Lock()
+			p.firstByteAt = time.Now()
+			if !p.wroteAt.IsZero() {
+				p.timing.TTFB += p.firstByteAt.Sub(p.wroteAt)
+			}
+			p.mu.Unlock()
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), t))
+}
+
+// finish records the body download phase and returns the collected timing.
+func (p *phaseRecorder) finish(bodyReadAt time.Time) model.Timing {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.firstByteAt.IsZero() && bodyReadAt.After(p.firstByteAt) {
+		p.timing.Download = bodyReadAt.Sub(p.firstByteAt)
+	}
+	return p.timing
+}
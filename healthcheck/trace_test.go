@@ -0,0 +1,40 @@
+package healthcheck
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestPhaseRecorder(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	req, err := http.NewRequest("GET", server.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	phases := &phaseRecorder{}
+	resp, err := server.Client().Do(phases.trace(req))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+	timing := phases.finish(time.Now())
+
+	if timing.Connect <= 0 {
+		t.Errorf("expected a connect phase, got %v", timing)
+	}
+	if timing.TLS <= 0 {
+		t.Errorf("expected a TLS phase, got %v", timing)
+	}
+	if timing.TTFB < 20*time.Millisecond {
+		t.Errorf("expected TTFB to include the server delay, got %v", timing)
+	}
+}
@@ -0,0 +1,431 @@
+// Package jsonschema validates JSON documents against a subset of JSON Schema
+// draft 2020-12. Supported keywords:
+//
+//	type, enum, const, $ref (local JSON pointers), $defs / definitions,
+//	properties, patternProperties, additionalProperties, required,
+//	minProperties, maxProperties, prefixItems, items, contains, minItems,
+//	maxItems, uniqueItems, minLength, maxLength, pattern, minimum, maximum,
+//	exclusiveMinimum, exclusiveMaximum, multipleOf, allOf, anyOf, oneOf, not
+//
+// Other keywords, including format, are treated as annotations and ignored.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type Schema struct {
+	always *bool // set for the boolean schemas true and false
+
+	types    []string
+	enum     []any
+	constVal any
+	hasConst bool
+	ref      string
+
+	properties           map[string]*Schema
+	patternProperties    []patternSchema
+	additionalProperties *Schema
+	required             []string
+	minProperties        *int
+	maxProperties        *int
+
+	prefixItems []*Schema
+	items       *Schema
+	contains    *Schema
+	minItems    *int
+	maxItems    *int
+	uniqueItems bool
+
+	minLength *int
+	maxLength *int
+	pattern   *regexp.Regexp
+
+	minimum          *float64
+	maximum          *float64
+	exclusiveMinimum *float64
+	exclusiveMaximum *float64
+	multipleOf       *float64
+
+	allOf []*Schema
+	anyOf []*Schema
+	oneOf []*Schema
+	not   *Schema
+
+	root *compiler
+}
+
+type patternSchema struct {
+	re     *regexp.Regexp
+	schema *Schema
+}
+
+type compiler struct {
+	doc  any
+	refs map[string]*Schema
+}
+
+// Compile parses a JSON Schema document.
+func Compile(data []byte) (*Schema, error) {
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("schema is not valid JSON: %w", err)
+	}
+	c := &compiler{doc: doc, refs: map[string]*Schema{}}
+	s, err := c.compile(doc, "#")
+	if err != nil {
+		return nil, err
+	}
+	c.refs["#"] = s
+	seen := map[*Schema]bool{}
+	if err := c.resolveAll(s, seen); err != nil {
+		return nil, err
+	}
+	done := map[*Schema]bool{}
+	for schema := range seen {
+		if err := c.checkProgress(schema, map[*Schema]bool{}, done); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (c *compiler) compile(v any, loc string) (*Schema, error) {
+	s := &Schema{root: c}
+	if b, ok := v.(bool); ok {
+		s.always = &b
+		return s, nil
+	}
+	obj, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: schema must be an object or a boolean", loc)
+	}
+	var err error
+	sub := func(key string) (*Schema, error) {
+		raw, ok := obj[key]
+		if !ok {
+			return nil, nil
+		}
+		return c.compile(raw, loc+"/"+escape(key))
+	}
+	subList := func(key string) ([]*Schema, error) {
+		raw, ok := obj[key]
+		if !ok {
+			return nil, nil
+		}
+		list, ok := raw.([]any)
+		if !ok {
+			return nil, fmt.Errorf("%s/%s: must be an array", loc, key)
+		}
+		schemas := make([]*Schema, len(list))
+		for i, item := range list {
+			if schemas[i], err = c.compile(item, fmt.Sprintf("%s/%s/%d", loc, key, i)); err != nil {
+				return nil, err
+			}
+		}
+		return schemas, nil
+	}
+	intKw := func(key string) (*int, error) {
+		raw, ok := obj[key]
+		if !ok {
+			return nil, nil
+		}
+		f, ok := raw.(float64)
+		if !ok || f < 0 || f != float64(int(f)) {
+			return nil, fmt.Errorf("%s/%s: must be a non-negative integer", loc, key)
+		}
+		n := int(f)
+		return &n, nil
+	}
+	numKw := func(key string) (*float64, error) {
+		raw, ok := obj[key]
+		if !ok {
+			return nil, nil
+		}
+		f, ok := raw.(float64)
+		if !ok {
+			return nil, fmt.Errorf("%s/%s: must be a number", loc, key)
+		}
+		return &f, nil
+	}
+
+	switch t := obj["type"].(type) {
+	case nil:
+	case string:
+		s.types = []string{t}
+	case []any:
+		for _, item := range t {
+			name, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s/type: must be a string or an array of strings", loc)
+			}
+			s.types = append(s.types, name)
+		}
+	default:
+		return nil, fmt.Errorf("%s/type: must be a string or an array of strings", loc)
+	}
+	for _, t := range s.types {
+		switch t {
+		case "null", "boolean", "object", "array", "number", "integer", "string":
+		default:
+			return nil, fmt.Errorf("%s/type: unknown type '%s'", loc, t)
+		}
+	}
+	if raw, ok := obj["enum"]; ok {
+		if s.enum, ok = raw.([]any); !ok {
+			return nil, fmt.Errorf("%s/enum: must be an array", loc)
+		}
+	}
+	if raw, ok := obj["const"]; ok {
+		s.constVal, s.hasConst = raw, true
+	}
+	if raw, ok := obj["$ref"]; ok {
+		if s.ref, ok = raw.(string); !ok || !strings.HasPrefix(s.ref, "#") {
+			return nil, fmt.Errorf("%s/$ref: only local references starting with '#' are supported", loc)
+		}
+	}
+
+	if raw, ok := obj["properties"]; ok {
+		props, ok := raw.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s/properties: must be an object", loc)
+		}
+		s.properties = map[string]*Schema{}
+		for name, p := range props {
+			if s.properties[name], err = c.compile(p, loc+"/properties/"+escape(name)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if raw, ok := obj["patternProperties"]; ok {
+		props, ok := raw.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s/patternProperties: must be an object", loc)
+		}
+		patterns := make([]string, 0, len(props))
+		for p := range props {
+			patterns = append(patterns, p)
+		}
+		sort.Strings(patterns)
+		for _, p := range patterns {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return nil, fmt.Errorf("%s/patternProperties: invalid pattern '%s': %v", loc, p, err)
+			}
+			ps, err := c.compile(props[p], loc+"/patternProperties/"+escape(p))
+			if err != nil {
+				return nil, err
+			}
+			s.patternProperties = append(s.patternProperties, patternSchema{re: re, schema: ps})
+		}
+	}
+	if s.additionalProperties, err = sub("additionalProperties"); err != nil {
+		return nil, err
+	}
+	if raw, ok := obj["required"]; ok {
+		list, ok := raw.([]any)
+		if !ok {
+			return nil, fmt.Errorf("%s/required: must be an array of strings", loc)
+		}
+		for _, item := range list {
+			name, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s/required: must be an array of strings", loc)
+			}
+			s.required = append(s.required, name)
+		}
+	}
+	if s.minProperties, err = intKw("minProperties"); err != nil {
+		return nil, err
+	}
+	if s.maxProperties, err = intKw("maxProperties"); err != nil {
+		return nil, err
+	}
+
+	if s.prefixItems, err = subList("prefixItems"); err != nil {
+		return nil, err
+	}
+	if s.items, err = sub("items"); err != nil {
+		return nil, err
+	}
+	if s.contains, err = sub("contains"); err != nil {
+		return nil, err
+	}
+	if s.minItems, err = intKw("minItems"); err != nil {
+		return nil, err
+	}
+	if s.maxItems, err = intKw("maxItems"); err != nil {
+		return nil, err
+	}
+	if raw, ok := obj["uniqueItems"]; ok {
+		if s.uniqueItems, ok = raw.(bool); !ok {
+			return nil, fmt.Errorf("%s/uniqueItems: must be a boolean", loc)
+		}
+	}
+
+	if s.minLength, err = intKw("minLength"); err != nil {
+		return nil, err
+	}
+	if s.maxLength, err = intKw("maxLength"); err != nil {
+		return nil, err
+	}
+	if raw, ok := obj["pattern"]; ok {
+		p, ok := raw.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s/pattern: must be a string", loc)
+		}
+		if s.pattern, err = regexp.Compile(p); err != nil {
+			return nil, fmt.Errorf("%s/pattern: invalid pattern '%s': %v", loc, p, err)
+		}
+	}
+
+	for key, dst := range map[string]**float64{
+		"minimum":          &s.minimum,
+		"maximum":          &s.maximum,
+		"exclusiveMinimum": &s.exclusiveMinimum,
+		"exclusiveMaximum": &s.exclusiveMaximum,
+		"multipleOf":       &s.multipleOf,
+	} {
+		if *dst, err = numKw(key); err != nil {
+			return nil, err
+		}
+	}
+	if s.multipleOf != nil && *s.multipleOf <= 0 {
+		return nil, fmt.Errorf("%s/multipleOf: must be greater than 0", loc)
+	}
+
+	if s.allOf, err = subList("allOf"); err != nil {
+		return nil, err
+	}
+	if s.anyOf, err = subList("anyOf"); err != nil {
+		return nil, err
+	}
+	if s.oneOf, err = subList("oneOf"); err != nil {
+		return nil, err
+	}
+	if s.not, err = sub("not"); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// resolveAll makes sure every $ref points at an existing schema so broken
+// references are reported at load time.
+func (c *compiler) resolveAll(s *Schema, seen map[*Schema]bool) error {
+	if s == nil || seen[s] {
+		return nil
+	}
+	seen[s] = true
+	if s.ref != "" {
+		target, err := c.resolve(s.ref)
+		if err != nil {
+			return err
+		}
+		if err := c.resolveAll(target, seen); err != nil {
+			return err
+		}
+	}
+	children := []*Schema{s.additionalProperties, s.items, s.contains, s.not}
+	for _, p := range s.properties {
+		children = append(children, p)
+	}
+	for _, p := range s.patternProperties {
+		children = append(children, p.schema)
+	}
+	children = append(children, s.prefixItems...)
+	children = append(children, s.allOf...)
+	children = append(children, s.anyOf...)
+	children = append(children, s.oneOf...)
+	for _, child := range children {
+		if err := c.resolveAll(child, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkProgress rejects $ref cycles that come back to a schema without
+// stepping into a property or an item, such as {"$ref": "#"}, because
+// validating them would never stop. Only keywords that apply to the same
+// value are followed.
+func (c *compiler) checkProgress(s *Schema, active, done map[*Schema]bool) error {
+	if s == nil || done[s] {
+		return nil
+	}
+	active[s] = true
+	if s.ref != "" {
+		target, err := c.resolve(s.ref)
+		if err != nil {
+			return err
+		}
+		if active[target] {
+			return fmt.Errorf("$ref '%s' refers back to itself without validating a property or item", s.ref)
+		}
+		if err := c.checkProgress(target, active, done); err != nil {
+			return err
+		}
+	}
+	children := []*Schema{s.not}
+	children = append(children, s.allOf...)
+	children = append(children, s.anyOf...)
+	children = append(children, s.oneOf...)
+	for _, child := range children {
+		if err := c.checkProgress(child, active, done); err != nil {
+			return err
+		}
+	}
+	delete(active, s)
+	done[s] = true
+	return nil
+}
+
+func (c *compiler) resolve(ref string) (*Schema, error) {
+	if s, ok := c.refs[ref]; ok {
+		return s, nil
+	}
+	pointer := strings.TrimPrefix(ref, "#")
+	v := c.doc
+	if pointer != "" {
+		if !strings.HasPrefix(pointer, "/") {
+			return nil, fmt.Errorf("$ref '%s': only JSON pointer fragments are supported", ref)
+		}
+		for _, part := range strings.Split(pointer[1:], "/") {
+			part = unescape(part)
+			switch node := v.(type) {
+			case map[string]any:
+				next, ok := node[part]
+				if !ok {
+					return nil, fmt.Errorf("$ref '%s' cannot be resolved", ref)
+				}
+				v = next
+			case []any:
+				i, err := strconv.Atoi(part)
+				if err != nil || i < 0 || i >= len(node) {
+					return nil, fmt.Errorf("$ref '%s' cannot be resolved", ref)
+				}
+				v = node[i]
+			default:
+				return nil, fmt.Errorf("$ref '%s' cannot be resolved", ref)
+			}
+		}
+	}
+	s, err := c.compile(v, ref)
+	if err != nil {
+		return nil, err
+	}
+	c.refs[ref] = s
+	return s, nil
+}
+
+func escape(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+func unescape(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~1", "/"), "~0", "~")
+}
@@ -0,0 +1,114 @@
+package jsonschema_test
+
+import (
+	"healthy-api/jsonschema"
+	"testing"
+)
+
+const userSchema = `{
+	"$defs": {
+		"id": {"type": "integer", "minimum": 1}
+	},
+	"type": "object",
+	"required": ["id", "name", "roles"],
+	"properties": {
+		"id": {"$ref": "#/$defs/id"},
+		"name": {"type": "string", "minLength": 1},
+		"email": {"type": ["string", "null"], "pattern": "@"},
+		"roles": {"type": "array", "items": {"enum": ["admin", "user"]}, "uniqueItems": true},
+		"meta": {"type": "object", "additionalProperties": false, "properties": {"v": {"const": 2}}}
+	}
+}`
+
+func TestValidate(t *testing.T) {
+	schema, err := jsonschema.Compile([]byte(userSchema))
+	if err != nil {
+		t.Fatalf("compile failed: %v", err)
+	}
+
+	cases := []struct {
+		name    string
+		doc     string
+		path    string
+		keyword string
+	}{
+		{"valid", `{"id": 3, "name": "sara", "email": null, "roles": ["admin"], "meta": {"v": 2}}`, "", ""},
+		{"type changed", `{"id": "3", "name": "sara", "roles": []}`, "/id", "type"},
+		{"ref minimum", `{"id": 0, "name": "sara", "roles": []}`, "/id", "minimum"},
+		{"missing", `{"id": 3, "roles": []}`, "", "required"},
+		{"enum in items", `{"id": 3, "name": "x", "roles": ["root"]}`, "/roles/0", "enum"},
+		{"unique", `{"id": 3, "name": "x", "roles": ["user", "user"]}`, "/roles", "uniqueItems"},
+		{"additional", `{"id": 3, "name": "x", "roles": [], "meta": {"v": 2, "x": 1}}`, "/meta/x", "additionalProperties"},
+		{"const", `{"id": 3, "name": "x", "roles": [], "meta": {"v": 3}}`, "/meta/v", "const"},
+		{"pattern", `{"id": 3, "name": "x", "roles": [], "email": "nope"}`, "/email", "pattern"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			errs, err := schema.ValidateJSON([]byte(tc.doc))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.keyword == "" {
+				if len(errs) != 0 {
+					t.Fatalf("expected no violations, got %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 {
+				t.Fatalf("expected exactly one violation, got %v", errs)
+			}
+			if errs[0].InstancePath != tc.path || errs[0].Keyword != tc.keyword {
+				t.Errorf("got %s %s, want %s %s", errs[0].InstancePath, errs[0].Keyword, tc.path, tc.keyword)
+			}
+		})
+	}
+}
+
+func TestCombinators(t *testing.T) {
+	schema, err := jsonschema.Compile([]byte(`{
+		"oneOf": [{"type": "integer"}, {"type": "number", "multipleOf": 0.5}],
+		"not": {"const": 4}
+	}`))
+	if err != nil {
+		t.Fatalf("compile failed: %v", err)
+	}
+	for doc, valid := range map[string]bool{"1.5": true, "3": false, "1.2": false, "4": false} {
+		errs, _ := schema.ValidateJSON([]byte(doc))
+		if (len(errs) == 0) != valid {
+			t.Errorf("%s: valid=%v, violations %v", doc, valid, errs)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, src := range []string{
+		`{"type": "text"}`,
+		`{"$ref": "#/$defs/missing"}`,
+		`{"$ref": "https://example.com/schema.json"}`,
+		`{"$ref": "#"}`,
+		`{"$defs": {"a": {"$ref": "#/$defs/a"}}, "$ref": "#/$defs/a"}`,
+		`{"$defs": {"a": {"allOf": [{"$ref": "#/$defs/b"}]}, "b": {"not": {"$ref": "#/$defs/a"}}}, "$ref": "#/$defs/a"}`,
+		`{"pattern": "("}`,
+		`{"minLength": -1}`,
+		`[1, 2]`,
+	} {
+		if _, err := jsonschema.Compile([]byte(src)); err == nil {
+			t.Errorf("expected an error for %s", src)
+		}
+	}
+}
+
+func TestRecursiveRef(t *testing.T) {
+	schema, err := jsonschema.Compile([]byte(`{
+		"type": "object",
+		"properties": {"children": {"type": "array", "items": {"$ref": "#"}}},
+		"required": ["children"]
+	}`))
+	if err != nil {
+		t.Fatalf("compile failed: %v", err)
+	}
+	errs, _ := schema.ValidateJSON([]byte(`{"children": [{"children": []}, {}]}`))
+	if len(errs) != 1 || errs[0].InstancePath != "/children/1" || errs[0].Keyword != "required" {
+		t.Errorf("unexpected violations: %v", errs)
+	}
+}
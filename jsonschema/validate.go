@@ -0,0 +1,308 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidationError describes one failed keyword. InstancePath is a JSON
+// pointer into the validated document ("" is the document itself).
+type ValidationError struct {
+	InstancePath string
+	Keyword      string
+	Message      string
+}
+
+func (e ValidationError) Error() string {
+	path := e.InstancePath
+	if path == "" {
+		path = "/"
+	}
+	return fmt.Sprintf("%s: %s: %s", path, e.Keyword, e.Message)
+}
+
+// ValidateJSON decodes data and validates it.
+func (s *Schema) ValidateJSON(data []byte) ([]ValidationError, error) {
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("body is not valid JSON: %w", err)
+	}
+	return s.Validate(doc), nil
+}
+
+// Validate checks a decoded JSON value (as produced by encoding/json) and
+// returns every violation found.
+func (s *Schema) Validate(doc any) []ValidationError {
+	var errs []ValidationError
+	s.validate(doc, "", &errs)
+	return errs
+}
+
+func (s *Schema) validate(v any, path string, errs *[]ValidationError) {
+	fail := func(keyword, format string, args ...any) {
+		*errs = append(*errs, ValidationError{InstancePath: path, Keyword: keyword, Message: fmt.Sprintf(format, args...)})
+	}
+	if s.always != nil {
+		if !*s.always {
+			fail("false", "no value is allowed here")
+		}
+		return
+	}
+	if s.ref != "" {
+		if target, err := s.root.resolve(s.ref); err != nil {
+			fail("$ref", "%v", err)
+		} else {
+			target.validate(v, path, errs)
+		}
+	}
+	if len(s.types) > 0 {
+		matched := false
+		for _, t := range s.types {
+			if hasType(v, t) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			fail("type", "expected %s, got %s", strings.Join(s.types, " or "), typeOf(v))
+		}
+	}
+	if s.enum != nil {
+		found := false
+		for _, e := range s.enum {
+			if equal(v, e) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("enum", "value %s is not one of the allowed values", short(v))
+		}
+	}
+	if s.hasConst && !equal(v, s.constVal) {
+		fail("const", "expected %s, got %s", short(s.constVal), short(v))
+	}
+
+	switch x := v.(type) {
+	case map[string]any:
+		s.validateObject(x, path, errs, fail)
+	case []any:
+		s.validateArray(x, path, errs, fail)
+	case string:
+		n := utf8.RuneCountInString(x)
+		if s.minLength != nil && n < *s.minLength {
+			fail("minLength", "length %d is shorter than %d", n, *s.minLength)
+		}
+		if s.maxLength != nil && n > *s.maxLength {
+			fail("maxLength", "length %d is longer than %d", n, *s.maxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(x) {
+			fail("pattern", "%s does not match '%s'", short(x), s.pattern)
+		}
+	case float64:
+		if s.minimum != nil && x < *s.minimum {
+			fail("minimum", "%v is less than %v", x, *s.minimum)
+		}
+		if s.maximum != nil && x > *s.maximum {
+			fail("maximum", "%v is greater than %v", x, *s.maximum)
+		}
+		if s.exclusiveMinimum != nil && x <= *s.exclusiveMinimum {
+			fail("exclusiveMinimum", "%v is not greater than %v", x, *s.exclusiveMinimum)
+		}
+		if s.exclusiveMaximum != nil && x >= *s.exclusiveMaximum {
+			fail("exclusiveMaximum", "%v is not less than %v", x, *s.exclusiveMaximum)
+		}
+		if s.multipleOf != nil {
+			q := x / *s.multipleOf
+			if math.Abs(q-math.Round(q)) > 1e-9 {
+				fail("multipleOf", "%v is not a multiple of %v", x, *s.multipleOf)
+			}
+		}
+	}
+
+	for _, sub := range s.allOf {
+		sub.validate(v, path, errs)
+	}
+	if len(s.anyOf) > 0 {
+		matched := false
+		for _, sub := range s.anyOf {
+			if sub.valid(v, path) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			fail("anyOf", "value does not match any of the %d schemas", len(s.anyOf))
+		}
+	}
+	if len(s.oneOf) > 0 {
+		count := 0
+		for _, sub := range s.oneOf {
+			if sub.valid(v, path) {
+				count++
+			}
+		}
+		if count != 1 {
+			fail("oneOf", "value matches %d of the schemas, expected exactly one", count)
+		}
+	}
+	if s.not != nil && s.not.valid(v, path) {
+		fail("not", "value must not match the schema")
+	}
+}
+
+func (s *Schema) validateObject(obj map[string]any, path string, errs *[]ValidationError, fail func(string, string, ...any)) {
+	for _, name := range s.required {
+		if _, ok := obj[name]; !ok {
+			fail("required", "missing property '%s'", name)
+		}
+	}
+	if s.minProperties != nil && len(obj) < *s.minProperties {
+		fail("minProperties", "has %d properties, needs at least %d", len(obj), *s.minProperties)
+	}
+	if s.maxProperties != nil && len(obj) > *s.maxProperties {
+		fail("maxProperties", "has %d properties, allows at most %d", len(obj), *s.maxProperties)
+	}
+	for _, name := range sortedKeys(obj) {
+		val := obj[name]
+		childPath := path + "/" + escape(name)
+		evaluated := false
+		if p, ok := s.properties[name]; ok {
+			p.validate(val, childPath, errs)
+			evaluated = true
+		}
+		for _, pp := range s.patternProperties {
+			if pp.re.MatchString(name) {
+				pp.schema.validate(val, childPath, errs)
+				evaluated = true
+			}
+		}
+		if !evaluated && s.additionalProperties != nil {
+			if s.additionalProperties.always != nil && !*s.additionalProperties.always {
+				*errs = append(*errs, ValidationError{InstancePath: childPath, Keyword: "additionalProperties", Message: fmt.Sprintf("property '%s' is not allowed", name)})
+				continue
+			}
+			s.additionalProperties.validate(val, childPath, errs)
+		}
+	}
+}
+
+func (s *Schema) validateArray(arr []any, path string, errs *[]ValidationError, fail func(string, string, ...any)) {
+	if s.minItems != nil && len(arr) < *s.minItems {
+		fail("minItems", "has %d items, needs at least %d", len(arr), *s.minItems)
+	}
+	if s.maxItems != nil && len(arr) > *s.maxItems {
+		fail("maxItems", "has %d items, allows at most %d", len(arr), *s.maxItems)
+	}
+	if s.uniqueItems {
+		for i := range arr {
+			for j := i + 1; j < len(arr); j++ {
+				if equal(arr[i], arr[j]) {
+					fail("uniqueItems", "items %d and %d are equal", i, j)
+				}
+			}
+		}
+	}
+	for i, item := range arr {
+		childPath := path + "/" + strconv.Itoa(i)
+		switch {
+		case i < len(s.prefixItems):
+			s.prefixItems[i].validate(item, childPath, errs)
+		case s.items != nil:
+			s.items.validate(item, childPath, errs)
+		}
+	}
+	if s.contains != nil {
+		found := false
+		for i, item := range arr {
+			if s.contains.valid(item, path+"/"+strconv.Itoa(i)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("contains", "no item matches the schema")
+		}
+	}
+}
+
+func (s *Schema) valid(v any, path string) bool {
+	var errs []ValidationError
+	s.validate(v, path, &errs)
+	return len(errs) == 0
+}
+
+func hasType(v any, t string) bool {
+	switch t {
+	case "null":
+		return v == nil
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "object":
+		_, ok := v.(map[string]any)
+		return ok
+	case "array":
+		_, ok := v.([]any)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f)
+	case "string":
+		_, ok := v.(string)
+		return ok
+	}
+	return false
+}
+
+func typeOf(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case float64:
+		if x == math.Trunc(x) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func equal(a, b any) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+func short(v any) string {
+	data, _ := json.Marshal(v)
+	s := string(data)
+	if len(s) > 40 {
+		s = s[:37] + "..."
+	}
+	return s
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -105,6 +106,10 @@ func checkTemplate(templ map[string]interface{}) error {
 		ServiceName: "test",
 		TimeStamp:   "Test",
 		URL:         "test",
+		Timing:      "test",
+		Trace:       "test",
+		State:       "test",
+		Severity:    "test",
 	})
 	return err
 
@@ -140,6 +145,239 @@ func loadWebhookNotifiers(cfg *model.Config, notifierRegistry *registry.Registry
 	return whCount
 }
 
+func loadTelegramNotifiers(cfg *model.Config, notifierRegistry *registry.Registry[notifier.Notifier], logger *slog.Logger) int {
+	tgCount := 0
+	for _, tg := range cfg.Notifiers.Telegrams {
+		if _, ok := notifierRegistry.Get(tg.ID); ok {
+			logger.Error("notifier_already_exists", "id", tg.ID)
+			os.Exit(1)
+		}
+		notifierInst, err := notifier.NewTelegramNotifier(tg, logger)
+		if err != nil {
+			logger.Error("invalid_telegram_notifier", "id", tg.ID, "error", err)
+			os.Exit(1)
+		}
+		tgCount++
+		notifierRegistry.Register(tg.ID, notifierInst)
+		// The bot token is part of the config, so only log the id.
+		logger.Info("notifier_registered",
+			"type", "telegram",
+			"id", tg.ID,
+			"api_base_url", notifierInst.APIBaseURL,
+		)
+	}
+	return tgCount
+}
+
+func loadSlackNotifiers(cfg *model.Config, notifierRegistry *registry.Registry[notifier.Notifier], logger *slog.Logger) int {
+	slackCount := 0
+	for _, sl := range cfg.Notifiers.Slacks {
+		if _, ok := notifierRegistry.Get(sl.ID); ok {
+			logger.Error("notifier_already_exists", "id", sl.ID)
+			os.Exit(1)
+		}
+		notifierInst, err := notifier.NewSlackNotifier(sl, logger)
+		if err != nil {
+			logger.Error("invalid_slack_notifier", "id", sl.ID, "error", err)
+			os.Exit(1)
+		}
+		slackCount++
+		notifierRegistry.Register(sl.ID, notifierInst)
+		logger.Info("notifier_registered",
+			"type", "slack",
+			"id", sl.ID,
+			"bot", sl.BotToken != "",
+		)
+	}
+	return slackCount
+}
+
+func loadDiscordNotifiers(cfg *model.Config, notifierRegistry *registry.Registry[notifier.Notifier], logger *slog.Logger) int {
+	discordCount := 0
+	for _, dc := range cfg.Notifiers.Discords {
+		if _, ok := notifierRegistry.Get(dc.ID); ok {
+			logger.Error("notifier_already_exists", "id", dc.ID)
+			os.Exit(1)
+		}
+		notifierInst, err := notifier.NewDiscordNotifier(dc, logger)
+		if err != nil {
+			logger.Error("invalid_discord_notifier", "id", dc.ID, "error", err)
+			os.Exit(1)
+		}
+		discordCount++
+		notifierRegistry.Register(dc.ID, notifierInst)
+		logger.Info("notifier_registered", "type", "discord", "id", dc.ID)
+	}
+	return discordCount
+}
+
+func loadTeamsNotifiers(cfg *model.Config, notifierRegistry *registry.Registry[notifier.Notifier], logger *slog.Logger) int {
+	teamsCount := 0
+	for _, tm := range cfg.Notifiers.MSTeams {
+		if _, ok := notifierRegistry.Get(tm.ID); ok {
+			logger.Error("notifier_already_exists", "id", tm.ID)
+			os.Exit(1)
+		}
+		notifierInst, err := notifier.NewTeamsNotifier(tm, logger)
+		if err != nil {
+			logger.Error("invalid_msteams_notifier", "id", tm.ID, "error", err)
+			os.Exit(1)
+		}
+		teamsCount++
+		notifierRegistry.Register(tm.ID, notifierInst)
+		logger.Info("notifier_registered", "type", "msteams", "id", tm.ID)
+	}
+	return teamsCount
+}
+
+func loadPagerDutyNotifiers(cfg *model.Config, notifierRegistry *registry.Registry[notifier.Notifier], logger *slog.Logger) int {
+	pdCount := 0
+	for _, pd := range cfg.Notifiers.PagerDuties {
+		if _, ok := notifierRegistry.Get(pd.ID); ok {
+			logger.Error("notifier_already_exists", "id", pd.ID)
+			os.Exit(1)
+		}
+		notifierInst := notifier.NewPagerDutyNotifier(pd, logger)
+		pdCount++
+		notifierRegistry.Register(pd.ID, notifierInst)
+		logger.Info("notifier_registered", "type", "pagerduty", "id", pd.ID, "events_url", notifierInst.EventsURL)
+	}
+	return pdCount
+}
+
+func loadOpsgenieNotifiers(cfg *model.Config, notifierRegistry *registry.Registry[notifier.Notifier], logger *slog.Logger) int {
+	ogCount := 0
+	for _, og := range cfg.Notifiers.Opsgenies {
+		if _, ok := notifierRegistry.Get(og.ID); ok {
+			logger.Error("notifier_already_exists", "id", og.ID)
+			os.Exit(1)
+		}
+		notifierInst, err := notifier.NewOpsgenieNotifier(og, logger)
+		if err != nil {
+			logger.Error("invalid_opsgenie_notifier", "id", og.ID, "error", err)
+			os.Exit(1)
+		}
+		ogCount++
+		notifierRegistry.Register(og.ID, notifierInst)
+		logger.Info("notifier_registered", "type", "opsgenie", "id", og.ID, "api_base_url", notifierInst.APIBaseURL)
+	}
+	return ogCount
+}
+
+func loadNtfyNotifiers(cfg *model.Config, notifierRegistry *registry.Registry[notifier.Notifier], logger *slog.Logger) int {
+	ntfyCount := 0
+	for _, nf := range cfg.Notifiers.Ntfys {
+		if _, ok := notifierRegistry.Get(nf.ID); ok {
+			logger.Error("notifier_already_exists", "id", nf.ID)
+			os.Exit(1)
+		}
+		notifierInst, err := notifier.NewNtfyNotifier(nf, logger)
+		if err != nil {
+			logger.Error("invalid_ntfy_notifier", "id", nf.ID, "error", err)
+			os.Exit(1)
+		}
+		ntfyCount++
+		notifierRegistry.Register(nf.ID, notifierInst)
+		logger.Info("notifier_registered", "type", "ntfy", "id", nf.ID, "server_url", notifierInst.ServerURL)
+	}
+	return ntfyCount
+}
+
+func loadGotifyNotifiers(cfg *model.Config, notifierRegistry *registry.Registry[notifier.Notifier], logger *slog.Logger) int {
+	gotifyCount := 0
+	for _, gf := range cfg.Notifiers.Gotifys {
+		if _, ok := notifierRegistry.Get(gf.ID); ok {
+			logger.Error("notifier_already_exists", "id", gf.ID)
+			os.Exit(1)
+		}
+		notifierInst, err := notifier.NewGotifyNotifier(gf, logger)
+		if err != nil {
+			logger.Error("invalid_gotify_notifier", "id", gf.ID, "error", err)
+			os.Exit(1)
+		}
+		gotifyCount++
+		notifierRegistry.Register(gf.ID, notifierInst)
+		logger.Info("notifier_registered", "type", "gotify", "id", gf.ID, "server_url", notifierInst.ServerURL)
+	}
+	return gotifyCount
+}
+
+func loadBaleNotifiers(cfg *model.Config, notifierRegistry *registry.Registry[notifier.Notifier], logger *slog.Logger) int {
+	baleCount := 0
+	for _, bl := range cfg.Notifiers.Bales {
+		if _, ok := notifierRegistry.Get(bl.ID); ok {
+			logger.Error("notifier_already_exists", "id", bl.ID)
+			os.Exit(1)
+		}
+		notifierInst, err := notifier.NewBaleNotifier(bl, logger)
+		if err != nil {
+			logger.Error("invalid_bale_notifier", "id", bl.ID, "error", err)
+			os.Exit(1)
+		}
+		baleCount++
+		notifierRegistry.Register(bl.ID, notifierInst)
+		logger.Info("notifier_registered", "type", "bale", "id", bl.ID, "api_base_url", notifierInst.APIBaseURL)
+	}
+	return baleCount
+}
+
+func loadKavenegarNotifiers(cfg *model.Config, notifierRegistry *registry.Registry[notifier.Notifier], logger *slog.Logger) int {
+	kavenegarCount := 0
+	for _, kv := range cfg.Notifiers.Kavenegars {
+		if _, ok := notifierRegistry.Get(kv.ID); ok {
+			logger.Error("notifier_already_exists", "id", kv.ID)
+			os.Exit(1)
+		}
+		notifierInst, err := notifier.NewKavenegarNotifier(kv, logger)
+		if err != nil {
+			logger.Error("invalid_kavenegar_notifier", "id", kv.ID, "error", err)
+			os.Exit(1)
+		}
+		kavenegarCount++
+		notifierRegistry.Register(kv.ID, notifierInst)
+		logger.Info("notifier_registered", "type", "kavenegar", "id", kv.ID, "lookup_template", notifierInst.LookupTemplate)
+	}
+	return kavenegarCount
+}
+
+func loadMatrixNotifiers(cfg *model.Config, notifierRegistry *registry.Registry[notifier.Notifier], logger *slog.Logger) int {
+	matrixCount := 0
+	for _, mx := range cfg.Notifiers.Matrices {
+		if _, ok := notifierRegistry.Get(mx.ID); ok {
+			logger.Error("notifier_already_exists", "id", mx.ID)
+			os.Exit(1)
+		}
+		notifierInst, err := notifier.NewMatrixNotifier(mx, logger)
+		if err != nil {
+			logger.Error("invalid_matrix_notifier", "id", mx.ID, "error", err)
+			os.Exit(1)
+		}
+		matrixCount++
+		notifierRegistry.Register(mx.ID, notifierInst)
+		logger.Info("notifier_registered", "type", "matrix", "id", mx.ID, "homeserver_url", notifierInst.HomeserverURL)
+	}
+	return matrixCount
+}
+
+func loadSyslogNotifiers(cfg *model.Config, notifierRegistry *registry.Registry[notifier.Notifier], logger *slog.Logger) int {
+	syslogCount := 0
+	for _, sl := range cfg.Notifiers.Syslogs {
+		if _, ok := notifierRegistry.Get(sl.ID); ok {
+			logger.Error("notifier_already_exists", "id", sl.ID)
+			os.Exit(1)
+		}
+		notifierInst, err := notifier.NewSyslogNotifier(sl, logger)
+		if err != nil {
+			logger.Error("invalid_syslog_notifier", "id", sl.ID, "error", err)
+			os.Exit(1)
+		}
+		syslogCount++
+		notifierRegistry.Register(sl.ID, notifierInst)
+		logger.Info("notifier_registered", "type", "syslog", "id", sl.ID, "network", notifierInst.Network, "address", notifierInst.Address)
+	}
+	return syslogCount
+}
+
 func PrintCondition(cond *model.Condition) {
 	bytes, err := json.MarshalIndent(cond, "", "  ")
 	if err != nil {
@@ -151,19 +389,43 @@ func PrintCondition(cond *model.Condition) {
 
 func loadConditions(cfg *model.Config, conditionRegistry *registry.Registry[model.Condition], logger *slog.Logger) int {
 	cCound := 0
+	conditionSet := model.NewConditionSet(cfg.Conditions)
+	invalid := false
 	for _, cond := range cfg.Conditions {
 
 		_, ok := conditionRegistry.Get(cond.ID)
 		if ok == true {
 			logger.Error("condition_already_exists", "id", cond.ID)
-			os.Exit(1)		}
-		if err := cond.Condition.Validate("conditions.condition"); err != nil {
-			logger.Error("invalid_error_condition", "error", err)
-			os.Exit(1)		}
+			invalid = true
+			continue
+		}
+		if cond.Condition == nil {
+			logger.Error("invalid_error_condition", "id", cond.ID, "error", "missing condition")
+			invalid = true
+			continue
+		}
+		// Ref problems are recorded on the ref nodes and reported below by
+		// Validate together with every other error of the tree.
+		_ = conditionSet.Resolve(cond.ID, cond.Condition)
+		if err := cond.Condition.Validate("conditions." + cond.ID); err != nil {
+			var errs model.ValidationErrors
+			if errors.As(err, &errs) {
+				for _, e := range errs.InFile(configPath) {
+					logger.Error("invalid_error_condition", "id", cond.ID, "error", e)
+				}
+			} else {
+				logger.Error("invalid_error_condition", "id", cond.ID, "error", err)
+			}
+			invalid = true
+			continue
+		}
 		cCound++
 		conditionRegistry.Register(cond.ID, *cond.Condition)
 
 	}
+	if invalid {
+		os.Exit(1)
+	}
 	return cCound
 }
 
@@ -210,45 +472,105 @@ func main() {
 	meliPayamakCount := loadPayamakPanels(cfg, notifierRegistry, logger) 
 	smtpCount := loadSMTPNotifiers(cfg, notifierRegistry, logger)
 	whCount := loadWebhookNotifiers(cfg, notifierRegistry, logger)
+	tgCount := loadTelegramNotifiers(cfg, notifierRegistry, logger)
+	slackCount := loadSlackNotifiers(cfg, notifierRegistry, logger)
+	discordCount := loadDiscordNotifiers(cfg, notifierRegistry, logger)
+	teamsCount := loadTeamsNotifiers(cfg, notifierRegistry, logger)
+	pdCount := loadPagerDutyNotifiers(cfg, notifierRegistry, logger)
+	ogCount := loadOpsgenieNotifiers(cfg, notifierRegistry, logger)
+	ntfyCount := loadNtfyNotifiers(cfg, notifierRegistry, logger)
+	gotifyCount := loadGotifyNotifiers(cfg, notifierRegistry, logger)
+	baleCount := loadBaleNotifiers(cfg, notifierRegistry, logger)
+	kavenegarCount := loadKavenegarNotifiers(cfg, notifierRegistry, logger)
+	matrixCount := loadMatrixNotifiers(cfg, notifierRegistry, logger)
+	syslogCount := loadSyslogNotifiers(cfg, notifierRegistry, logger)
 	fmt.Println()
 	fmt.Println("---------NOTIFIERS-----------")
 	fmt.Printf("%d ippanel regisered.\n", ippanelCount)
 	fmt.Printf("%d meli_payamak_panel registered.\n", meliPayamakCount)
 	fmt.Printf("%d smtp regisered.\n", smtpCount)
 	fmt.Printf("%d webhook regisered.\n", whCount)
+	fmt.Printf("%d telegram registered.\n", tgCount)
+	fmt.Printf("%d slack registered.\n", slackCount)
+	fmt.Printf("%d discord registered.\n", discordCount)
+	fmt.Printf("%d msteams registered.\n", teamsCount)
+	fmt.Printf("%d pagerduty registered.\n", pdCount)
+	fmt.Printf("%d opsgenie registered.\n", ogCount)
+	fmt.Printf("%d ntfy registered.\n", ntfyCount)
+	fmt.Printf("%d gotify registered.\n", gotifyCount)
+	fmt.Printf("%d bale registered.\n", baleCount)
+	fmt.Printf("%d kavenegar registered.\n", kavenegarCount)
+	fmt.Printf("%d matrix registered.\n", matrixCount)
+	fmt.Printf("%d syslog registered.\n", syslogCount)
 	fmt.Println("---------NOTIFIERS-----------")
 	fmt.Println()
 	cCount := loadConditions(cfg, conditionRegistry, logger)
 	fmt.Printf("%d condition found.\n\n", cCount)
 
 	fmt.Printf("%d service found.\n\n", len(cfg.Services))
+	conditionSet := model.NewConditionSet(cfg.Conditions)
 	for n, svc := range cfg.Services {
 		n++
 		fmt.Printf("Service [%d]: %s\n", n, svc.Name)
 		fmt.Println("  URL:", svc.URL)
-		fmt.Println("  Period:", svc.CheckPeriod)
-		fmt.Println("  Condition id:", svc.ConditionName)
-		fmt.Println("  SleepOnFail:", svc.SleepOnFail)
+		fmt.Println("  Period:", svc.CheckInterval())
+		fmt.Println("  Condition:", svc.ConditionLabel())
+		fmt.Println("  SleepOnFail:", svc.FailInterval())
 		fmt.Println("  Targets count:", len(svc.Targets))
 		fmt.Println("  User-Agent:", svc.UserAgent)
 		fmt.Println("  Threshold:", svc.Threshold)
 	
 		fmt.Println("----")
 		for _, v := range svc.Targets {
-			_, ok := notifierRegistry.Get(v.NotifierID)
+			target, ok := notifierRegistry.Get(v.NotifierID)
 			if ok == false {
 				fmt.Printf("\n\n[ERROR] notifier with id: '%s' not found.for service: `%s`\n\n\n", v.NotifierID, svc.Name)
 				os.Exit(1)
 			}
+			if rv, ok := target.(notifier.RecipientValidator); ok {
+				if err := rv.ValidateRecipients(v.Recipients); err != nil {
+					fmt.Printf("\n\n[ERROR] target '%s' of service `%s`: %v\n\n\n", v.NotifierID, svc.Name, err)
+					os.Exit(1)
+				}
+			}
+			for _, severity := range v.Severities {
+				if err := severity.Validate(); err != nil {
+					fmt.Printf("\n\n[ERROR] target '%s' of service `%s`: %v\n\n\n", v.NotifierID, svc.Name, err)
+					os.Exit(1)
+				}
+			}
 
 		}
 
+		inline, err := svc.InlineCondition()
+		if err != nil {
+			fmt.Printf("\n\n[ERROR] %v\n\n\n", err)
+			os.Exit(1)
+		}
+		if inline == nil {
+			if _, ok := conditionRegistry.Get(svc.ConditionName); !ok {
+				fmt.Printf("\n\n[ERROR] condition with id: '%s' not found.for service: `%s`\n\n\n", svc.ConditionName, svc.Name)
+				os.Exit(1)
+			}
+		} else {
+			_ = conditionSet.Resolve("", inline)
+			if err := inline.Validate("services." + svc.Name + ".condition"); err != nil {
+				var errs model.ValidationErrors
+				if errors.As(err, &errs) {
+					err = errs.InFile(configPath)
+				}
+				fmt.Printf("\n\n[ERROR] invalid condition for service `%s`:\n%v\n\n\n", svc.Name, err)
+				os.Exit(1)
+			}
+			svc.Condition = inline
+		}
+
 		hc := healthcheck.HealthChecker{
 			Service:           svc,
 			NotifierRegistry:  notifierRegistry,
 			ConditionRegistry: conditionRegistry,
 			Client: &http.Client{
-				Timeout: time.Duration(15) * time.Second,
+				Timeout: svc.RequestTimeout(),
 			},
 			Logger: logger,
 		}
@@ -0,0 +1,354 @@
+package markup
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Selector is a compiled CSS selector group. Supported syntax: type and
+// universal selectors, #id, .class, attribute selectors ([a], [a=v], [a~=v],
+// [a^=v], [a$=v], [a*=v]), :first-child, :last-child, :nth-child(n), the
+// descendant, >, + and ~ combinators, and comma separated groups.
+type Selector struct {
+	groups [][]selectorStep
+}
+
+type selectorStep struct {
+	combinator byte // 0 for the leftmost step, otherwise ' ', '>', '+' or '~'
+	compound   compound
+}
+
+type compound struct {
+	tag     string
+	id      string
+	classes []string
+	attrs   []attrSelector
+	pseudo  []pseudoSelector
+}
+
+type attrSelector struct {
+	name, op, value string
+}
+
+type pseudoSelector struct {
+	name string
+	n    int
+}
+
+func CompileSelector(src string) (*Selector, error) {
+	s := &Selector{}
+	for _, group := range splitTopLevel(src, ',') {
+		steps, err := parseSelectorGroup(strings.TrimSpace(group))
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector '%s': %w", src, err)
+		}
+		s.groups = append(s.groups, steps)
+	}
+	return s, nil
+}
+
+// Select returns the matching elements in document order.
+func (s *Selector) Select(root *Node) []*Node {
+	var out []*Node
+	for _, n := range root.descendants() {
+		for _, steps := range s.groups {
+			if matchSteps(n, steps) {
+				out = append(out, n)
+				break
+			}
+		}
+	}
+	return out
+}
+
+func splitTopLevel(s string, sep byte) []string {
+	var parts []string
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '(':
+			depth++
+		case c == ']' || c == ')':
+			depth--
+		case c == sep && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func parseSelectorGroup(src string) ([]selectorStep, error) {
+	if src == "" {
+		return nil, fmt.Errorf("empty selector")
+	}
+	var steps []selectorStep
+	var combinator byte
+	i := 0
+	for i < len(src) {
+		// Whitespace and explicit combinators between compounds.
+		sawSpace := false
+		for i < len(src) && src[i] == ' ' {
+			sawSpace = true
+			i++
+		}
+		if i < len(src) && strings.IndexByte(">+~", src[i]) >= 0 {
+			if len(steps) == 0 {
+				return nil, fmt.Errorf("selector cannot start with '%c'", src[i])
+			}
+			combinator = src[i]
+			i++
+			for i < len(src) && src[i] == ' ' {
+				i++
+			}
+		} else if sawSpace && len(steps) > 0 {
+			combinator = ' '
+		}
+		if i >= len(src) {
+			if combinator != 0 && combinator != ' ' {
+				return nil, fmt.Errorf("dangling combinator '%c'", combinator)
+			}
+			break
+		}
+		c, n, err := parseCompound(src[i:])
+		if err != nil {
+			return nil, err
+		}
+		i += n
+		steps = append(steps, selectorStep{combinator: combinator, compound: c})
+		combinator = 0
+	}
+	return steps, nil
+}
+
+func isNameChar(c byte) bool {
+	return c == '-' || c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+func readName(s string) string {
+	i := 0
+	for i < len(s) && isNameChar(s[i]) {
+		i++
+	}
+	return s[:i]
+}
+
+func parseCompound(s string) (compound, int, error) {
+	var c compound
+	i := 0
+	if i < len(s) && s[i] == '*' {
+		i++
+	} else if name := readName(s); name != "" {
+		c.tag = strings.ToLower(name)
+		i += len(name)
+	}
+	for i < len(s) {
+		switch s[i] {
+		case '#', '.':
+			name := readName(s[i+1:])
+			if name == "" {
+				return c, 0, fmt.Errorf("expected a name after '%c'", s[i])
+			}
+			if s[i] == '#' {
+				c.id = name
+			} else {
+				c.classes = append(c.classes, name)
+			}
+			i += 1 + len(name)
+		case '[':
+			end := strings.IndexByte(s[i:], ']')
+			if end < 0 {
+				return c, 0, fmt.Errorf("unterminated attribute selector")
+			}
+			a, err := parseAttrSelector(s[i+1 : i+end])
+			if err != nil {
+				return c, 0, err
+			}
+			c.attrs = append(c.attrs, a)
+			i += end + 1
+		case ':':
+			name := readName(s[i+1:])
+			i += 1 + len(name)
+			p := pseudoSelector{name: name}
+			switch name {
+			case "first-child", "last-child":
+			case "nth-child":
+				if i >= len(s) || s[i] != '(' {
+					return c, 0, fmt.Errorf(":nth-child needs an argument")
+				}
+				end := strings.IndexByte(s[i:], ')')
+				if end < 0 {
+					return c, 0, fmt.Errorf("unterminated :nth-child")
+				}
+				n, err := strconv.Atoi(strings.TrimSpace(s[i+1 : i+end]))
+				if err != nil || n < 1 {
+					return c, 0, fmt.Errorf(":nth-child only supports positive integers")
+				}
+				p.n = n
+				i += end + 1
+			default:
+				return c, 0, fmt.Errorf("unsupported pseudo-class ':%s'", name)
+			}
+			c.pseudo = append(c.pseudo, p)
+		default:
+			if i == 0 {
+				return c, 0, fmt.Errorf("unexpected '%c'", s[i])
+			}
+			return c, i, nil
+		}
+	}
+	if i == 0 {
+		return c, 0, fmt.Errorf("empty compound selector")
+	}
+	return c, i, nil
+}
+
+func parseAttrSelector(s string) (attrSelector, error) {
+	s = strings.TrimSpace(s)
+	name := readName(s)
+	if name == "" {
+		return attrSelector{}, fmt.Errorf("attribute selector needs a name")
+	}
+	a := attrSelector{name: name}
+	rest := strings.TrimSpace(s[len(name):])
+	if rest == "" {
+		return a, nil
+	}
+	for _, op := range []string{"~=", "^=", "$=", "*=", "="} {
+		if strings.HasPrefix(rest, op) {
+			a.op = op
+			a.value = strings.Trim(strings.TrimSpace(rest[len(op):]), `"'`)
+			return a, nil
+		}
+	}
+	return attrSelector{}, fmt.Errorf("unsupported attribute operator in '[%s]'", s)
+}
+
+func matchSteps(n *Node, steps []selectorStep) bool {
+	last := len(steps) - 1
+	if !steps[last].compound.matches(n) {
+		return false
+	}
+	if last == 0 {
+		return true
+	}
+	rest := steps[:last]
+	switch steps[last].combinator {
+	case '>':
+		return n.Parent != nil && n.Parent.Type == ElementNode && matchSteps(n.Parent, rest)
+	case ' ':
+		for p := n.Parent; p != nil && p.Type == ElementNode; p = p.Parent {
+			if matchSteps(p, rest) {
+				return true
+			}
+		}
+	case '+':
+		if prev := previousSiblings(n); len(prev) > 0 {
+			return matchSteps(prev[len(prev)-1], rest)
+		}
+	case '~':
+		for _, p := range previousSiblings(n) {
+			if matchSteps(p, rest) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func previousSiblings(n *Node) []*Node {
+	if n.Parent == nil {
+		return nil
+	}
+	var out []*Node
+	for _, s := range n.Parent.elementChildren() {
+		if s == n {
+			break
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func (c *compound) matches(n *Node) bool {
+	if n.Type != ElementNode {
+		return false
+	}
+	if c.tag != "" && !strings.EqualFold(c.tag, n.Name) {
+		return false
+	}
+	if c.id != "" {
+		if id, _ := n.Attr("id"); id != c.id {
+			return false
+		}
+	}
+	if len(c.classes) > 0 {
+		class, _ := n.Attr("class")
+		have := strings.Fields(class)
+		for _, want := range c.classes {
+			if !contains(have, want) {
+				return false
+			}
+		}
+	}
+	for _, a := range c.attrs {
+		v, ok := n.Attr(a.name)
+		if !ok {
+			return false
+		}
+		switch a.op {
+		case "=":
+			ok = v == a.value
+		case "~=":
+			ok = contains(strings.Fields(v), a.value)
+		case "^=":
+			ok = strings.HasPrefix(v, a.value)
+		case "$=":
+			ok = strings.HasSuffix(v, a.value)
+		case "*=":
+			ok = strings.Contains(v, a.value)
+		}
+		if !ok {
+			return false
+		}
+	}
+	for _, p := range c.pseudo {
+		if n.Parent == nil {
+			return false
+		}
+		siblings := n.Parent.elementChildren()
+		switch p.name {
+		case "first-child":
+			if siblings[0] != n {
+				return false
+			}
+		case "last-child":
+			if siblings[len(siblings)-1] != n {
+				return false
+			}
+		case "nth-child":
+			if len(siblings) < p.n || siblings[p.n-1] != n {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+// Package markup parses HTML and XML documents into a small DOM that can be
+// queried with a subset of CSS selectors and XPath 1.0.
+package markup
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+type NodeType int
+
+const (
+	DocumentNode NodeType = iota
+	ElementNode
+	TextNode
+)
+
+type Attr struct {
+	Name  string
+	Value string
+}
+
+type Node struct {
+	Type     NodeType
+	Name     string // local element name, lowercased for HTML
+	Attrs    []Attr
+	Data     string // text content of a TextNode
+	Parent   *Node
+	Children []*Node
+}
+
+// Attr returns the value of the named attribute.
+func (n *Node) Attr(name string) (string, bool) {
+	for _, a := range n.Attrs {
+		if strings.EqualFold(a.Name, name) {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+// Text returns the concatenated text of the node and its descendants.
+func (n *Node) Text() string {
+	if n.Type == TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	var walk func(*Node)
+	walk = func(x *Node) {
+		for _, c := range x.Children {
+			if c.Type == TextNode {
+				sb.WriteString(c.Data)
+			} else {
+				walk(c)
+			}
+		}
+	}
+	walk(n)
+	return sb.String()
+}
+
+func (n *Node) elementChildren() []*Node {
+	var out []*Node
+	for _, c := range n.Children {
+		if c.Type == ElementNode {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// descendants returns all element descendants in document order.
+func (n *Node) descendants() []*Node {
+	var out []*Node
+	var walk func(*Node)
+	walk = func(x *Node) {
+		for _, c := range x.Children {
+			if c.Type == ElementNode {
+				out = append(out, c)
+				walk(c)
+			}
+		}
+	}
+	walk(n)
+	return out
+}
+
+// impliedEnd lists, per HTML start tag, the open elements it implicitly
+// closes, e.g. a new <li> ends the previous unclosed <li>.
+var impliedEnd = map[string][]string{
+	"li":     {"li"},
+	"dt":     {"dt", "dd"},
+	"dd":     {"dt", "dd"},
+	"tr":     {"tr", "td", "th"},
+	"td":     {"td", "th"},
+	"th":     {"td", "th"},
+	"option": {"option"},
+	"p":      {"p"},
+	"div":    {"p"},
+	"ul":     {"p"},
+	"ol":     {"p"},
+	"table":  {"p"},
+}
+
+var scopeElements = map[string]bool{
+	"ul": true, "ol": true, "dl": true, "table": true, "tbody": true, "thead": true,
+	"tfoot": true, "select": true, "html": true, "body": true,
+}
+
+func closeImplied(cur *Node, tag string) *Node {
+	closes := impliedEnd[tag]
+	if closes == nil {
+		return cur
+	}
+	for n := cur; n != nil && n.Type == ElementNode; n = n.Parent {
+		if contains(closes, n.Name) {
+			return n.Parent
+		}
+		if scopeElements[n.Name] {
+			break
+		}
+	}
+	return cur
+}
+
+// ParseXML parses an XML document. Namespaces are ignored; elements are
+// matched by their local name.
+func ParseXML(data []byte) (*Node, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	root := &Node{Type: DocumentNode}
+	cur := root
+	for {
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			el := &Node{Type: ElementNode, Name: t.Name.Local, Parent: cur}
+			for _, a := range t.Attr {
+				el.Attrs = append(el.Attrs, Attr{Name: a.Name.Local, Value: a.Value})
+			}
+			cur.Children = append(cur.Children, el)
+			cur = el
+		case xml.EndElement:
+			cur = cur.Parent
+		case xml.CharData:
+			cur.Children = append(cur.Children, &Node{Type: TextNode, Data: string(t), Parent: cur})
+		}
+	}
+	return root, nil
+}
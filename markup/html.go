@@ -0,0 +1,284 @@
+package markup
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strconv"
+	"strings"
+)
+
+// voidElements never have content, so their start tag also ends them.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true,
+	"hr": true, "img": true, "input": true, "keygen": true, "link": true,
+	"meta": true, "param": true, "source": true, "track": true, "wbr": true,
+}
+
+// rawTextElements hold text up to their end tag. Script and style contents
+// are dropped because they are not markup; title and textarea keep theirs.
+var rawTextElements = map[string]bool{
+	"script": false, "style": false, "title": true, "textarea": true,
+}
+
+// ParseHTML parses an HTML document the way browsers tokenize it: input
+// that cannot start a tag, such as "a < b", is text, attribute values may
+// be unquoted and attribute names may use any character but whitespace,
+// "/", ">" and "=", so Vue's :class and @click are kept. Unclosed elements
+// are closed implicitly, and a tag cut off by the end of input is dropped.
+func ParseHTML(data []byte) *Node {
+	root := &Node{Type: DocumentNode}
+	cur := root
+	text := func(s string) {
+		if s != "" {
+			cur.Children = append(cur.Children, &Node{Type: TextNode, Data: unescapeHTML(s), Parent: cur})
+		}
+	}
+	z := &htmlTokenizer{data: data}
+	for z.pos < len(z.data) {
+		lt := bytes.IndexByte(z.data[z.pos:], '<')
+		if lt < 0 {
+			text(string(z.data[z.pos:]))
+			break
+		}
+		text(string(z.data[z.pos : z.pos+lt]))
+		z.pos += lt
+		switch {
+		case z.startsTag():
+			name, attrs, selfClosing, ok := z.tag()
+			if !ok {
+				return root
+			}
+			cur = closeImplied(cur, name)
+			el := &Node{Type: ElementNode, Name: name, Attrs: attrs, Parent: cur}
+			cur.Children = append(cur.Children, el)
+			if keep, raw := rawTextElements[name]; raw && !selfClosing {
+				content := z.rawText(name)
+				if keep && content != "" {
+					el.Children = append(el.Children, &Node{Type: TextNode, Data: unescapeHTML(content), Parent: el})
+				}
+			} else if !voidElements[name] && !selfClosing {
+				cur = el
+			}
+		case z.startsEndTag():
+			z.pos += 2
+			name, _, _, ok := z.tag()
+			if !ok {
+				return root
+			}
+			for n := cur; n != nil && n.Type == ElementNode; n = n.Parent {
+				if n.Name == name {
+					cur = n.Parent
+					break
+				}
+			}
+		case bytes.HasPrefix(z.data[z.pos:], []byte("<!--")):
+			z.skipPast("-->", 4)
+		case z.startsMarkupDeclaration():
+			// Doctype, CDATA, processing instructions and "</>" carry
+			// no content.
+			z.skipPast(">", 2)
+		default:
+			text("<")
+			z.pos++
+		}
+	}
+	return root
+}
+
+type htmlTokenizer struct {
+	data []byte
+	pos  int
+}
+
+func isASCIILetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isHTMLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+func (z *htmlTokenizer) at(offset int) byte {
+	if z.pos+offset < len(z.data) {
+		return z.data[z.pos+offset]
+	}
+	return 0
+}
+
+func (z *htmlTokenizer) startsTag() bool {
+	return isASCIILetter(z.at(1))
+}
+
+func (z *htmlTokenizer) startsEndTag() bool {
+	return z.at(1) == '/' && isASCIILetter(z.at(2))
+}
+
+func (z *htmlTokenizer) startsMarkupDeclaration() bool {
+	c := z.at(1)
+	return c == '!' || c == '?' || c == '/'
+}
+
+// skipPast moves past the next end marker, searching from offset, or to
+// the end of input.
+func (z *htmlTokenizer) skipPast(end string, offset int) {
+	start := min(z.pos+offset, len(z.data))
+	if i := bytes.Index(z.data[start:], []byte(end)); i >= 0 {
+		z.pos = start + i + len(end)
+	} else {
+		z.pos = len(z.data)
+	}
+}
+
+// tag reads a tag name and its attributes, starting at the name for end
+// tags and at "<" for start tags. It reports false when the input ends
+// inside the tag.
+func (z *htmlTokenizer) tag() (name string, attrs []Attr, selfClosing, ok bool) {
+	if z.at(0) == '<' {
+		z.pos++
+	}
+	name = strings.ToLower(z.until(func(c byte) bool { return isHTMLSpace(c) || c == '/' || c == '>' }))
+	for {
+		for isHTMLSpace(z.at(0)) {
+			z.pos++
+		}
+		if z.pos >= len(z.data) {
+			return "", nil, false, false
+		}
+		switch z.at(0) {
+		case '>':
+			z.pos++
+			return name, attrs, selfClosing, true
+		case '/':
+			z.pos++
+			selfClosing = z.at(0) == '>'
+			continue
+		}
+		selfClosing = false
+		// A leading "=" belongs to the name.
+		first := z.data[z.pos]
+		z.pos++
+		attr := Attr{Name: strings.ToLower(string(first) + z.until(func(c byte) bool {
+			return isHTMLSpace(c) || c == '/' || c == '>' || c == '='
+		}))}
+		for isHTMLSpace(z.at(0)) {
+			z.pos++
+		}
+		if z.at(0) == '=' {
+			z.pos++
+			for isHTMLSpace(z.at(0)) {
+				z.pos++
+			}
+			attr.Value = unescapeHTML(z.attrValue())
+		}
+		if !hasAttr(attrs, attr.Name) {
+			attrs = append(attrs, attr)
+		}
+	}
+}
+
+func (z *htmlTokenizer) attrValue() string {
+	if q := z.at(0); q == '"' || q == '\'' {
+		z.pos++
+		value := z.until(func(c byte) bool { return c == q })
+		z.pos = min(z.pos+1, len(z.data))
+		return value
+	}
+	return z.until(func(c byte) bool { return isHTMLSpace(c) || c == '>' })
+}
+
+func (z *htmlTokenizer) until(stop func(byte) bool) string {
+	start := z.pos
+	for z.pos < len(z.data) && !stop(z.data[z.pos]) {
+		z.pos++
+	}
+	return string(z.data[start:z.pos])
+}
+
+// rawText returns the content up to the end tag of the named element and
+// moves past that tag.
+func (z *htmlTokenizer) rawText(name string) string {
+	start := z.pos
+	for {
+		i := bytes.Index(z.data[z.pos:], []byte("</"))
+		if i < 0 {
+			z.pos = len(z.data)
+			return string(z.data[start:])
+		}
+		z.pos += i
+		end := z.pos + 2 + len(name)
+		if end <= len(z.data) && strings.EqualFold(string(z.data[z.pos+2:end]), name) &&
+			(end == len(z.data) || isHTMLSpace(z.data[end]) || z.data[end] == '/' || z.data[end] == '>') {
+			content := string(z.data[start:z.pos])
+			z.skipPast(">", 2)
+			return content
+		}
+		z.pos += 2
+	}
+}
+
+func hasAttr(attrs []Attr, name string) bool {
+	for _, a := range attrs {
+		if a.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// unescapeHTML replaces character references. Anything that is not a
+// complete reference, like the "&b=2" of a query string, is kept as is.
+func unescapeHTML(s string) string {
+	if !strings.Contains(s, "&") {
+		return s
+	}
+	var sb strings.Builder
+	for {
+		amp := strings.IndexByte(s, '&')
+		if amp < 0 {
+			sb.WriteString(s)
+			return sb.String()
+		}
+		sb.WriteString(s[:amp])
+		s = s[amp:]
+		semi := strings.IndexByte(s, ';')
+		if semi < 0 || semi > 33 {
+			sb.WriteString(s)
+			return sb.String()
+		}
+		if r, ok := characterReference(s[1:semi]); ok {
+			sb.WriteString(r)
+			s = s[semi+1:]
+		} else {
+			sb.WriteByte('&')
+			s = s[1:]
+		}
+	}
+}
+
+func characterReference(ref string) (string, bool) {
+	if num, ok := strings.CutPrefix(ref, "#"); ok {
+		base := 10
+		if hex, ok := strings.CutPrefix(strings.ToLower(num), "x"); ok {
+			num, base = hex, 16
+		}
+		code, err := strconv.ParseUint(num, base, 32)
+		if err != nil || code == 0 || code > 0x10FFFF {
+			return "", false
+		}
+		return string(rune(code)), true
+	}
+	switch ref {
+	case "amp":
+		return "&", true
+	case "lt":
+		return "<", true
+	case "gt":
+		return ">", true
+	case "quot":
+		return `"`, true
+	case "apos":
+		return "'", true
+	}
+	r, ok := xml.HTMLEntity[ref]
+	return r, ok
+}
@@ -0,0 +1,191 @@
+package markup_test
+
+import (
+	"healthy-api/markup"
+	"strings"
+	"testing"
+)
+
+const page = `<!DOCTYPE html>
+<html>
+<head><title>Shop</title>
+<script>if (a < b && c) { document.write("<p>") }</script>
+</head>
+<body>
+  <div id="status" class="banner ok" data-state="up">All systems <b>operational</b></div>
+  <ul class=products>
+    <li class="item">Tea<br>
+    <li class="item sale">Coffee
+    <li class="item">Cocoa
+  </ul>
+  <p>&copy; 2024 &nbsp;ACME</p>
+</body>
+</html>`
+
+const soap = `<?xml version="1.0"?>
+<soap:Envelope xmlns:soap="http://www.w3.org/2003/05/soap-envelope" xmlns:m="https://example.com/stock">
+  <soap:Body>
+    <m:GetPriceResponse>
+      <m:Price currency="USD">34.5</m:Price>
+      <m:Price currency="EUR">31.9</m:Price>
+      <m:Status>OK</m:Status>
+    </m:GetPriceResponse>
+  </soap:Body>
+</soap:Envelope>`
+
+func TestSelector(t *testing.T) {
+	doc := markup.ParseHTML([]byte(page))
+	cases := []struct {
+		selector string
+		count    int
+		first    string
+	}{
+		{"div#status.ok", 1, "All systems operational"},
+		{"#status > b", 1, "operational"},
+		{"[data-state=up]", 1, "All systems operational"},
+		{"ul.products li.item", 3, "Tea"},
+		{"li.sale", 1, "Coffee"},
+		{"li:first-child, li:last-child", 2, "Tea"},
+		{"li.sale + li", 1, "Cocoa"},
+		{"title", 1, "Shop"},
+		{"div.missing", 0, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.selector, func(t *testing.T) {
+			sel, err := markup.CompileSelector(tc.selector)
+			if err != nil {
+				t.Fatalf("compile failed: %v", err)
+			}
+			nodes := sel.Select(doc)
+			if len(nodes) != tc.count {
+				t.Fatalf("got %d matches, want %d", len(nodes), tc.count)
+			}
+			if tc.count > 0 && strings.TrimSpace(nodes[0].Text()) != tc.first {
+				t.Errorf("first match text = %q, want %q", strings.TrimSpace(nodes[0].Text()), tc.first)
+			}
+		})
+	}
+
+	for _, bad := range []string{"", "> p", "div[", "p:hover", "li:nth-child(x)"} {
+		if _, err := markup.CompileSelector(bad); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+func TestParseHTML_Malformed(t *testing.T) {
+	cases := []struct {
+		name     string
+		html     string
+		selector string
+		count    int
+		first    string
+	}{
+		{"less-than in text", `<p>a < b</p><p id="after">c</p>`, "p", 2, "a < b"},
+		{"unquoted query string", `<a href=/x?a=1&b=2&amp;c=3>link</a><span>after</span>`, "a[href='/x?a=1&b=2&c=3'] + span", 1, "after"},
+		{"vue attributes", `<div :class="{ok: up}" @click="refresh" v-if=up>On</div><b>after</b>`, "div[v-if=up] + b", 1, "after"},
+		{"unclosed tags", `<div><p>one<p>two<span>three</div><i>four`, "div > p", 2, "one"},
+		{"stray end tags", `</b><em>x</em></i></div><em>y</em>`, "em", 2, "x"},
+		{"comments and cdata", `<!-- <p>hidden</p> --><![CDATA[<p>]]><p>shown</p>`, "p", 1, "shown"},
+		{"cut off tag", `<p>kept</p><p class="ok`, "p", 1, "kept"},
+		{"script with markup", `<script>if (a </b> b) document.write("</p><p>")</script><p>x</p>`, "p", 1, "x"},
+		{"entities", `<title>Tom &amp; Jerry &copy; &#169; &#xA9; &bogus; AT&T</title>`, "title", 1, "Tom & Jerry © © © &bogus; AT&T"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := markup.ParseHTML([]byte(tc.html))
+			sel, err := markup.CompileSelector(tc.selector)
+			if err != nil {
+				t.Fatalf("compile failed: %v", err)
+			}
+			nodes := sel.Select(doc)
+			if len(nodes) != tc.count {
+				t.Fatalf("got %d matches, want %d", len(nodes), tc.count)
+			}
+			if got := strings.TrimSpace(nodes[0].Text()); got != tc.first {
+				t.Errorf("first match text = %q, want %q", got, tc.first)
+			}
+		})
+	}
+
+	doc := markup.ParseHTML([]byte(`<div :class="{ok: up}" @click="refresh">On</div>`))
+	div, _ := markup.CompileSelector("div")
+	for name, want := range map[string]string{":class": "{ok: up}", "@click": "refresh"} {
+		if got, ok := div.Select(doc)[0].Attr(name); !ok || got != want {
+			t.Errorf("attribute %s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestParseXML_ReportsErrors(t *testing.T) {
+	_, err := markup.ParseXML([]byte("<a>\n<b>x</a>"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected a syntax error on line 2, got %v", err)
+	}
+}
+
+func TestXPath(t *testing.T) {
+	doc, err := markup.ParseXML([]byte(soap))
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	cases := []struct {
+		path   string
+		values []string
+	}{
+		{"/Envelope/Body/GetPriceResponse/Status", []string{"OK"}},
+		{"//Price", []string{"34.5", "31.9"}},
+		{"//Price[@currency='EUR']", []string{"31.9"}},
+		{"//Price[2]", []string{"31.9"}},
+		{"//Price[last()]/@currency", []string{"EUR"}},
+		{"//GetPriceResponse[Status='OK']/Price[. > 32]", []string{"34.5"}},
+		{"//Price[contains(@currency, 'U') and not(. < 30)]/text()", []string{"34.5", "31.9"}},
+		{"//soap:Body/m:GetPriceResponse/*[position() >= 3]", []string{"OK"}},
+		{"//Missing", nil},
+		{"//*[local-name()='Price'][1]", []string{"34.5"}},
+		{"/*[local-name()='Envelope']/*/*/*[local-name() != 'Price']", []string{"OK"}},
+		{"//Status | //Price", []string{"34.5", "31.9", "OK"}},
+		{"//Price/@currency | //Price", []string{"34.5", "USD", "31.9", "EUR"}},
+		{"(//Price)[last()]", []string{"31.9"}},
+		{"(//Status | //Price)[1]/@currency", []string{"USD"}},
+		{"count(//Price)", []string{"2"}},
+		{"count(//Price[. > 40] | //Missing)", []string{"0"}},
+		{"local-name(/*)", []string{"Envelope"}},
+		{"local-name(//Price/@*)", []string{"currency"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.path, func(t *testing.T) {
+			x, err := markup.CompileXPath(tc.path)
+			if err != nil {
+				t.Fatalf("compile failed: %v", err)
+			}
+			matches := x.Select(doc)
+			var got []string
+			for _, m := range matches {
+				got = append(got, m.Value())
+			}
+			if strings.Join(got, "|") != strings.Join(tc.values, "|") {
+				t.Errorf("got %q, want %q", got, tc.values)
+			}
+		})
+	}
+
+	for bad, msg := range map[string]string{
+		"":                              "empty expression",
+		"//Price[":                      "",
+		"//@currency/x":                 "must be last",
+		"//Price[contains(@a)]":         "two arguments",
+		"(//Price":                      "expected ')'",
+		"//Price | ":                    "",
+		"sum(//Price)":                  "unsupported function sum()",
+		"//Price[string-length(.) > 2]": "unsupported function string-length()",
+		"//Price[count(x) > 1]":         "unsupported function count()",
+		"/descendant::Price":            "unsupported axis descendant::",
+		"count(//Price) > 1":            "unexpected '> 1'",
+	} {
+		_, err := markup.CompileXPath(bad)
+		if err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("%q: expected an error containing %q, got %v", bad, msg, err)
+		}
+	}
+}
@@ -0,0 +1,781 @@
+package markup
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// XPath is a compiled XPath 1.0 subset: absolute and relative location paths
+// with the child (/) and descendant (//) axes, name tests, *, ., ..,
+// @attribute, text(), unions (a | b), parenthesized paths with predicates
+// and further steps ((//h1)[1]/a), and predicates built from positions,
+// last(), comparisons of @attr, text(), ., local-name() or child element
+// values with literals, contains(), starts-with(), not(), and/or. The whole
+// expression may also be count(path) or local-name(path). Other functions,
+// axes and arithmetic are rejected when compiling.
+type XPath struct {
+	src  string
+	expr xpathExpr
+}
+
+// Match is one XPath result: an element, a text node, an attribute, or the
+// value of count() and local-name().
+type Match struct {
+	Node  *Node
+	Attr  string // attribute name when the match is an attribute
+	value string // result of a function when Node is nil
+}
+
+// Value returns the string value of the match.
+func (m Match) Value() string {
+	switch {
+	case m.Node == nil:
+		return m.value
+	case m.Attr != "":
+		v, _ := m.Node.Attr(m.Attr)
+		return v
+	}
+	return m.Node.Text()
+}
+
+type xpathStep struct {
+	descendant bool
+	kind       string // "name", "attr", "text", "self", "parent"
+	name       string // name test, "*" matches everything
+	predicates []predicate
+}
+
+// xpathExpr is evaluated against the document root.
+type xpathExpr interface {
+	eval(root *Node) []Match
+}
+
+type (
+	// pathExpr is a location path, or a parenthesized expression with
+	// predicates followed by relative steps.
+	pathExpr struct {
+		filter     xpathExpr
+		predicates []predicate
+		steps      []xpathStep
+	}
+	unionExpr     []xpathExpr
+	countExpr     struct{ x xpathExpr }
+	localNameExpr struct{ x xpathExpr }
+)
+
+func CompileXPath(src string) (*XPath, error) {
+	p := &xpathParser{s: strings.TrimSpace(src)}
+	expr, err := p.parseExpr()
+	if p.skipSpace(); err == nil && !p.eof() {
+		err = fmt.Errorf("unexpected '%s'", p.s[p.i:])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid xpath '%s': %w", src, err)
+	}
+	return &XPath{src: src, expr: expr}, nil
+}
+
+func (x *XPath) String() string { return x.src }
+
+// Select evaluates the expression against the document root.
+func (x *XPath) Select(root *Node) []Match {
+	return x.expr.eval(root)
+}
+
+func (x *pathExpr) eval(root *Node) []Match {
+	ctx := []Match{{Node: root}}
+	if x.filter != nil {
+		ctx = x.filter.eval(root)
+		for _, pred := range x.predicates {
+			ctx = filterMatches(ctx, pred)
+		}
+	}
+	return selectSteps(ctx, x.steps)
+}
+
+func (x unionExpr) eval(root *Node) []Match {
+	var out []Match
+	seen := map[Match]bool{}
+	for _, part := range x {
+		for _, m := range part.eval(root) {
+			if !seen[m] {
+				seen[m] = true
+				out = append(out, m)
+			}
+		}
+	}
+	sortDocumentOrder(root, out)
+	return out
+}
+
+func (x countExpr) eval(root *Node) []Match {
+	return []Match{{value: strconv.Itoa(len(x.x.eval(root)))}}
+}
+
+// eval returns the local name of the first match, or "" for none.
+func (x localNameExpr) eval(root *Node) []Match {
+	matches := x.x.eval(root)
+	if len(matches) == 0 {
+		return []Match{{}}
+	}
+	return []Match{{value: localName(matches[0])}}
+}
+
+func localName(m Match) string {
+	if m.Attr != "" {
+		return m.Attr
+	}
+	if m.Node.Type == ElementNode {
+		return m.Node.Name
+	}
+	return ""
+}
+
+func selectSteps(ctx []Match, steps []xpathStep) []Match {
+	for _, step := range steps {
+		var next []Match
+		seen := map[Match]bool{}
+		for _, m := range ctx {
+			if m.Node == nil || m.Attr != "" {
+				continue
+			}
+			candidates := step.candidates(m.Node)
+			for _, pred := range step.predicates {
+				candidates = filterMatches(candidates, pred)
+			}
+			for _, c := range candidates {
+				if !seen[c] {
+					seen[c] = true
+					next = append(next, c)
+				}
+			}
+		}
+		ctx = next
+	}
+	return ctx
+}
+
+func filterMatches(candidates []Match, pred predicate) []Match {
+	var kept []Match
+	for i, c := range candidates {
+		if pred.eval(c, i+1, len(candidates)) {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// sortDocumentOrder sorts matches by their position in the document, with
+// attributes right after their element.
+func sortDocumentOrder(root *Node, matches []Match) {
+	index := map[*Node]int{}
+	var walk func(*Node)
+	walk = func(n *Node) {
+		index[n] = len(index)
+		for _, c := range n.Children {
+			walk(c)
+		}
+	}
+	walk(root)
+	attrIndex := func(m Match) int {
+		for i, a := range m.Node.Attrs {
+			if a.Name == m.Attr {
+				return i + 1
+			}
+		}
+		return 0
+	}
+	sort.SliceStable(matches, func(a, b int) bool {
+		if ia, ib := index[matches[a].Node], index[matches[b].Node]; ia != ib {
+			return ia < ib
+		}
+		return attrIndex(matches[a]) < attrIndex(matches[b])
+	})
+}
+
+func (s *xpathStep) candidates(n *Node) []Match {
+	var nodes []*Node
+	switch s.kind {
+	case "self":
+		return []Match{{Node: n}}
+	case "parent":
+		if n.Parent == nil {
+			return nil
+		}
+		return []Match{{Node: n.Parent}}
+	}
+	if s.descendant {
+		nodes = append([]*Node{n}, n.descendants()...)
+	} else {
+		nodes = []*Node{n}
+	}
+	var out []Match
+	for _, base := range nodes {
+		switch s.kind {
+		case "attr":
+			for _, a := range base.Attrs {
+				if s.name == "*" || strings.EqualFold(s.name, a.Name) {
+					out = append(out, Match{Node: base, Attr: a.Name})
+				}
+			}
+		case "text":
+			for _, c := range base.Children {
+				if c.Type == TextNode {
+					out = append(out, Match{Node: c})
+				}
+			}
+		case "name":
+			for _, c := range base.Children {
+				if c.Type == ElementNode && (s.name == "*" || strings.EqualFold(s.name, c.Name)) {
+					out = append(out, Match{Node: c})
+				}
+			}
+		}
+	}
+	return out
+}
+
+type xpathParser struct {
+	s string
+	i int
+}
+
+func (p *xpathParser) eof() bool { return p.i >= len(p.s) }
+
+func (p *xpathParser) skipSpace() {
+	for !p.eof() && p.s[p.i] == ' ' {
+		p.i++
+	}
+}
+
+func (p *xpathParser) consume(tok string) bool {
+	p.skipSpace()
+	if strings.HasPrefix(p.s[p.i:], tok) {
+		p.i += len(tok)
+		return true
+	}
+	return false
+}
+
+// parseExpr parses a whole expression: count() or local-name() of a path,
+// or a union of paths.
+func (p *xpathParser) parseExpr() (xpathExpr, error) {
+	if p.s == "" {
+		return nil, fmt.Errorf("empty expression")
+	}
+	for _, fn := range []string{"count", "local-name"} {
+		if !p.consume(fn + "(") {
+			continue
+		}
+		x, err := p.parseUnion()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume(")") {
+			return nil, fmt.Errorf("expected ')' after %s() argument", fn)
+		}
+		if fn == "count" {
+			return countExpr{x}, nil
+		}
+		return localNameExpr{x}, nil
+	}
+	return p.parseUnion()
+}
+
+func (p *xpathParser) parseUnion() (xpathExpr, error) {
+	x, err := p.parsePathExpr()
+	if err != nil {
+		return nil, err
+	}
+	union := unionExpr{x}
+	for p.consume("|") {
+		x, err := p.parsePathExpr()
+		if err != nil {
+			return nil, err
+		}
+		union = append(union, x)
+	}
+	if len(union) == 1 {
+		return union[0], nil
+	}
+	return union, nil
+}
+
+func (p *xpathParser) parsePathExpr() (xpathExpr, error) {
+	x := &pathExpr{}
+	p.skipSpace()
+	if !p.consume("(") {
+		steps, err := p.parsePath()
+		x.steps = steps
+		return x, err
+	}
+	filter, err := p.parseUnion()
+	if err != nil {
+		return nil, err
+	}
+	if !p.consume(")") {
+		return nil, fmt.Errorf("expected ')'")
+	}
+	x.filter = filter
+	if x.predicates, err = p.parsePredicates(); err != nil {
+		return nil, err
+	}
+	if p.peek("/") {
+		x.steps, err = p.parsePath()
+	}
+	return x, err
+}
+
+func (p *xpathParser) peek(tok string) bool {
+	p.skipSpace()
+	return strings.HasPrefix(p.s[p.i:], tok)
+}
+
+// parsePath parses location steps up to the end of the expression or the
+// next "|" or ")".
+func (p *xpathParser) parsePath() ([]xpathStep, error) {
+	var steps []xpathStep
+	descendant := false
+	switch {
+	case p.consume("//"):
+		descendant = true
+	case p.consume("/"):
+	}
+	for {
+		step, err := p.parseStep()
+		if err != nil {
+			return nil, err
+		}
+		step.descendant = descendant
+		steps = append(steps, step)
+		if p.eof() || p.peek("|") || p.peek(")") {
+			return steps, nil
+		}
+		if p.consume("//") {
+			descendant = true
+		} else if p.consume("/") {
+			descendant = false
+		} else {
+			return nil, fmt.Errorf("unexpected '%s'", p.s[p.i:])
+		}
+		if steps[len(steps)-1].kind == "attr" || steps[len(steps)-1].kind == "text" {
+			return nil, fmt.Errorf("attribute and text() steps must be last")
+		}
+	}
+}
+
+func (p *xpathParser) parseStep() (xpathStep, error) {
+	p.skipSpace()
+	var step xpathStep
+	switch {
+	case p.consume(".."):
+		return xpathStep{kind: "parent"}, nil
+	case p.consume("."):
+		return xpathStep{kind: "self"}, nil
+	case p.consume("text()"):
+		step.kind = "text"
+	case p.consume("@"):
+		step.kind = "attr"
+		if p.consume("*") {
+			step.name = "*"
+		} else if step.name = p.readName(); step.name == "" {
+			return step, fmt.Errorf("expected an attribute name after '@'")
+		}
+	case p.consume("*"):
+		step.kind, step.name = "name", "*"
+	default:
+		step.kind = "name"
+		if step.name = p.readName(); step.name == "" {
+			if p.eof() {
+				return step, fmt.Errorf("expected a step at end of expression")
+			}
+			return step, fmt.Errorf("unexpected '%c'", p.s[p.i])
+		}
+		if err := p.unsupported(step.name); err != nil {
+			return step, err
+		}
+	}
+	var err error
+	step.predicates, err = p.parsePredicates()
+	return step, err
+}
+
+func (p *xpathParser) parsePredicates() ([]predicate, error) {
+	var preds []predicate
+	for p.consume("[") {
+		pred, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume("]") {
+			return nil, fmt.Errorf("expected ']'")
+		}
+		preds = append(preds, pred)
+	}
+	return preds, nil
+}
+
+// unsupported reports a name followed by "(" or "::", which is a function
+// or an axis outside the supported subset.
+func (p *xpathParser) unsupported(name string) error {
+	switch {
+	case p.peek("("):
+		return fmt.Errorf("unsupported function %s()", name)
+	case p.peek("::"):
+		return fmt.Errorf("unsupported axis %s::, use / and //", name)
+	}
+	return nil
+}
+
+func (p *xpathParser) readName() string {
+	p.skipSpace()
+	start := p.i
+	for !p.eof() && (isNameChar(p.s[p.i]) || p.s[p.i] == ':' && !strings.HasPrefix(p.s[p.i:], "::") || p.s[p.i] == '.') {
+		p.i++
+	}
+	name := p.s[start:p.i]
+	// Namespace prefixes are ignored because elements are matched by local name.
+	if idx := strings.LastIndexByte(name, ':'); idx >= 0 {
+		name = name[idx+1:]
+	}
+	return name
+}
+
+// predicate is evaluated for a candidate at a 1-based position among size candidates.
+type predicate interface {
+	eval(m Match, pos, size int) bool
+}
+
+type (
+	orPred  struct{ l, r predicate }
+	andPred struct{ l, r predicate }
+	notPred struct{ x predicate }
+	posPred struct {
+		op   string
+		n    int
+		last bool
+	}
+	existPred struct{ o operand }
+	cmpPred   struct {
+		o     operand
+		op    string
+		value string
+	}
+	funcPred struct {
+		name  string
+		o     operand
+		value string
+	}
+)
+
+// operand selects the string values a predicate compares: an attribute,
+// text(), the context node itself, its local-name(), or child elements by
+// name.
+type operand struct {
+	kind string // "attr", "text", "self", "local-name", "child"
+	name string
+}
+
+func (o operand) values(m Match) []string {
+	if m.Node == nil || m.Attr != "" {
+		switch {
+		case o.kind == "self":
+			return []string{m.Value()}
+		case o.kind == "local-name" && m.Node != nil:
+			return []string{m.Attr}
+		}
+		return nil
+	}
+	switch o.kind {
+	case "local-name":
+		return []string{localName(m)}
+	case "attr":
+		if v, ok := m.Node.Attr(o.name); ok {
+			return []string{v}
+		}
+	case "text":
+		var out []string
+		for _, c := range m.Node.Children {
+			if c.Type == TextNode {
+				out = append(out, c.Data)
+			}
+		}
+		return out
+	case "self":
+		return []string{m.Node.Text()}
+	case "child":
+		var out []string
+		for _, c := range m.Node.elementChildren() {
+			if strings.EqualFold(c.Name, o.name) {
+				out = append(out, c.Text())
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func (x orPred) eval(m Match, pos, size int) bool {
+	return x.l.eval(m, pos, size) || x.r.eval(m, pos, size)
+}
+func (x andPred) eval(m Match, pos, size int) bool {
+	return x.l.eval(m, pos, size) && x.r.eval(m, pos, size)
+}
+func (x notPred) eval(m Match, pos, size int) bool { return !x.x.eval(m, pos, size) }
+func (x existPred) eval(m Match, _, _ int) bool    { return len(x.o.values(m)) > 0 }
+
+func (x posPred) eval(_ Match, pos, size int) bool {
+	n := x.n
+	if x.last {
+		n = size
+	}
+	return compareNumbers(float64(pos), x.op, float64(n))
+}
+
+func (x cmpPred) eval(m Match, _, _ int) bool {
+	for _, v := range x.o.values(m) {
+		if compareValues(v, x.op, x.value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (x funcPred) eval(m Match, _, _ int) bool {
+	for _, v := range x.o.values(m) {
+		if (x.name == "contains" && strings.Contains(v, x.value)) ||
+			(x.name == "starts-with" && strings.HasPrefix(v, x.value)) {
+			return true
+		}
+	}
+	return false
+}
+
+func compareValues(a, op, b string) bool {
+	fa, errA := strconv.ParseFloat(strings.TrimSpace(a), 64)
+	fb, errB := strconv.ParseFloat(strings.TrimSpace(b), 64)
+	if errA == nil && errB == nil {
+		return compareNumbers(fa, op, fb)
+	}
+	switch op {
+	case "=":
+		return a == b
+	case "!=":
+		return a != b
+	}
+	return false
+}
+
+func compareNumbers(a float64, op string, b float64) bool {
+	switch op {
+	case "=":
+		return a == b
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	}
+	return false
+}
+
+func (p *xpathParser) parseOr() (predicate, error) {
+	l, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.consumeWord("or") {
+		r, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l = orPred{l, r}
+	}
+	return l, nil
+}
+
+func (p *xpathParser) parseAnd() (predicate, error) {
+	l, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for p.consumeWord("and") {
+		r, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		l = andPred{l, r}
+	}
+	return l, nil
+}
+
+func (p *xpathParser) consumeWord(w string) bool {
+	p.skipSpace()
+	rest := p.s[p.i:]
+	if strings.HasPrefix(rest, w) && (len(rest) == len(w) || !isNameChar(rest[len(w)])) {
+		p.i += len(w)
+		return true
+	}
+	return false
+}
+
+func (p *xpathParser) parseTerm() (predicate, error) {
+	p.skipSpace()
+	switch {
+	case p.consume("("):
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume(")") {
+			return nil, fmt.Errorf("expected ')'")
+		}
+		return x, nil
+	case p.consume("not("):
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume(")") {
+			return nil, fmt.Errorf("expected ')'")
+		}
+		return notPred{x}, nil
+	case p.consume("last()"):
+		return posPred{op: "=", last: true}, nil
+	case p.consume("position()"):
+		op := p.parseOp()
+		if op == "" {
+			return nil, fmt.Errorf("expected a comparison after position()")
+		}
+		p.skipSpace()
+		if p.consume("last()") {
+			return posPred{op: op, last: true}, nil
+		}
+		n, err := p.parseInt()
+		if err != nil {
+			return nil, err
+		}
+		return posPred{op: op, n: n}, nil
+	}
+	for _, fn := range []string{"contains", "starts-with"} {
+		if p.consume(fn + "(") {
+			o, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			if !p.consume(",") {
+				return nil, fmt.Errorf("%s() takes two arguments", fn)
+			}
+			lit, err := p.parseLiteral()
+			if err != nil {
+				return nil, err
+			}
+			if !p.consume(")") {
+				return nil, fmt.Errorf("expected ')'")
+			}
+			return funcPred{name: fn, o: o, value: lit}, nil
+		}
+	}
+	if !p.eof() && p.s[p.i] >= '0' && p.s[p.i] <= '9' {
+		n, err := p.parseInt()
+		if err != nil {
+			return nil, err
+		}
+		return posPred{op: "=", n: n}, nil
+	}
+	o, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	op := p.parseOp()
+	if op == "" {
+		return existPred{o}, nil
+	}
+	lit, err := p.parseLiteral()
+	if err != nil {
+		return nil, err
+	}
+	return cmpPred{o: o, op: op, value: lit}, nil
+}
+
+func (p *xpathParser) parseOperand() (operand, error) {
+	p.skipSpace()
+	switch {
+	case p.consume("text()"):
+		return operand{kind: "text"}, nil
+	case p.consume("@"):
+		name := p.readName()
+		if name == "" {
+			return operand{}, fmt.Errorf("expected an attribute name after '@'")
+		}
+		return operand{kind: "attr", name: name}, nil
+	case p.consume("local-name()"):
+		return operand{kind: "local-name"}, nil
+	case p.consume("."):
+		return operand{kind: "self"}, nil
+	}
+	name := p.readName()
+	if name == "" {
+		if p.eof() {
+			return operand{}, fmt.Errorf("unexpected end of predicate")
+		}
+		return operand{}, fmt.Errorf("unexpected '%c' in predicate", p.s[p.i])
+	}
+	if err := p.unsupported(name); err != nil {
+		return operand{}, err
+	}
+	return operand{kind: "child", name: name}, nil
+}
+
+func (p *xpathParser) parseOp() string {
+	for _, op := range []string{"!=", "<=", ">=", "=", "<", ">"} {
+		if p.consume(op) {
+			return op
+		}
+	}
+	return ""
+}
+
+func (p *xpathParser) parseInt() (int, error) {
+	p.skipSpace()
+	start := p.i
+	for !p.eof() && p.s[p.i] >= '0' && p.s[p.i] <= '9' {
+		p.i++
+	}
+	n, err := strconv.Atoi(p.s[start:p.i])
+	if err != nil {
+		return 0, fmt.Errorf("expected a number")
+	}
+	return n, nil
+}
+
+func (p *xpathParser) parseLiteral() (string, error) {
+	p.skipSpace()
+	if p.eof() {
+		return "", fmt.Errorf("expected a literal")
+	}
+	if q := p.s[p.i]; q == '\'' || q == '"' {
+		end := strings.IndexByte(p.s[p.i+1:], q)
+		if end < 0 {
+			return "", fmt.Errorf("unterminated string literal")
+		}
+		lit := p.s[p.i+1 : p.i+1+end]
+		p.i += end + 2
+		return lit, nil
+	}
+	start := p.i
+	for !p.eof() && (p.s[p.i] >= '0' && p.s[p.i] <= '9' || p.s[p.i] == '.' || p.s[p.i] == '-') {
+		p.i++
+	}
+	if start == p.i {
+		return "", fmt.Errorf("expected a quoted string or a number")
+	}
+	return p.s[start:p.i], nil
+}
@@ -0,0 +1,13 @@
+package model
+
+// Bale is a Bale messenger bot. Its Bot API mirrors Telegram's sendMessage,
+// and recipients are chat IDs as well.
+type Bale struct {
+	ID       string `yaml:"id"`
+	BotToken string `yaml:"bot_token"`
+	// APIBaseURL is https://tapi.bale.ai by default.
+	APIBaseURL string `yaml:"api_base_url,omitempty"`
+	// Template is plain text. Values are wrapped in Unicode isolates so
+	// Latin names and URLs render correctly inside Persian sentences.
+	Template string `yaml:"template,omitempty"`
+}
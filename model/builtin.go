@@ -0,0 +1,161 @@
+package model
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	conditionevaluator "healthy-api/evaluator"
+)
+
+// The built-in condition types are ordinary registered types; trees built in
+// Go use Leaf or the constructors below to get the same evaluators.
+func init() {
+	conditionevaluator.Register(string(ConditionRegex), func(config conditionevaluator.Config) (conditionevaluator.Evaluator, error) {
+		r := &RegexCondition{}
+		return regexEvaluator{r}, config.Decode(r)
+	})
+	conditionevaluator.Register(string(ConditionStatusCode), func(config conditionevaluator.Config) (conditionevaluator.Evaluator, error) {
+		s := &StatusCodeCondition{}
+		return statusCodeEvaluator{s}, config.Decode(s)
+	})
+	conditionevaluator.Register(string(ConditionHeader), func(config conditionevaluator.Config) (conditionevaluator.Evaluator, error) {
+		var headers []HeaderCondition
+		return headerEvaluator{headers: &headers}, config.Decode(&headers)
+	})
+	conditionevaluator.Register(string(ConditionResponseTime), func(config conditionevaluator.Config) (conditionevaluator.Evaluator, error) {
+		rt := &ResponseTimeCondition{}
+		return responseTimeEvaluator{rt}, config.Decode(rt)
+	})
+	register[ExpressionCondition](ConditionExpr)
+	register[JSONSchemaCondition](ConditionJSONSchema)
+	register[CSSSelectorCondition](ConditionCSSSelector)
+	register[XPathCondition](ConditionXPath)
+	register[TimingCondition](ConditionTiming)
+	register[WindowCondition](ConditionWindow)
+	register[ContentDriftCondition](ConditionContentDrift)
+	register[RedirectCondition](ConditionRedirect)
+}
+
+// register adds a condition type whose config decodes into a new T.
+func register[T any, PT interface {
+	*T
+	conditionevaluator.Evaluator
+}](key ConditionType) {
+	conditionevaluator.Register(string(key), func(config conditionevaluator.Config) (conditionevaluator.Evaluator, error) {
+		c := PT(new(T))
+		return c, config.Decode(c)
+	})
+}
+
+type regexEvaluator struct{ *RegexCondition }
+
+func (r regexEvaluator) Evaluate(ctx *ResponseContext) conditionevaluator.Result {
+	res := conditionevaluator.Result{
+		Expected: fmt.Sprintf("body matching /%s/", r.Regex),
+		Actual:   fmt.Sprintf("%d bytes", len(ctx.Body)),
+	}
+	re, err := r.compile()
+	switch {
+	case err != nil:
+		res.Reason = fmt.Sprintf("Invalid regex pattern '%s': %v", r.Regex, err)
+	case !re.Match(ctx.Body):
+		res.Reason = fmt.Sprintf("Regex pattern '%s' not found in body", r.Regex)
+	default:
+		res.IsHealthy = true
+	}
+	return res
+}
+
+type statusCodeEvaluator struct{ *StatusCodeCondition }
+
+func (s statusCodeEvaluator) Evaluate(ctx *ResponseContext) conditionevaluator.Result {
+	res := conditionevaluator.Result{Expected: s.String()}
+	switch resp := ctx.Response; {
+	case resp == nil:
+		res.Reason = "No response received"
+	case !s.Matches(resp.StatusCode):
+		res.Actual = fmt.Sprint(resp.StatusCode)
+		res.Reason = fmt.Sprintf("Expected status %s, but got %d", s, resp.StatusCode)
+	default:
+		res.Actual = fmt.Sprint(resp.StatusCode)
+		res.IsHealthy = true
+	}
+	return res
+}
+
+type headerEvaluator struct{ headers *[]HeaderCondition }
+
+func (h headerEvaluator) Validate() error {
+	headers := *h.headers
+	for i := range headers {
+		if err := headers[i].Validate(); err != nil {
+			return fmt.Errorf("[%d]: %v", i, err)
+		}
+	}
+	return nil
+}
+
+func (h headerEvaluator) Evaluate(ctx *ResponseContext) conditionevaluator.Result {
+	var want, got []string
+	for _, hc := range *h.headers {
+		want = append(want, hc.String())
+		if ctx.Response != nil {
+			got = append(got, fmt.Sprintf("%s=%s", http.CanonicalHeaderKey(hc.Key), strings.Join(ctx.Response.Header.Values(hc.Key), ",")))
+		}
+	}
+	res := conditionevaluator.Result{Expected: strings.Join(want, "; "), Actual: strings.Join(got, "; ")}
+	if ctx.Response == nil {
+		res.Reason = "No response headers available"
+		return res
+	}
+	for _, hc := range *h.headers {
+		if ok, reason := hc.Check(ctx.Response.Header); !ok {
+			res.Reason = reason
+			return res
+		}
+	}
+	res.IsHealthy = true
+	return res
+}
+
+type responseTimeEvaluator struct{ *ResponseTimeCondition }
+
+func (rt responseTimeEvaluator) Evaluate(ctx *ResponseContext) conditionevaluator.Result {
+	res := conditionevaluator.Result{
+		Expected: "<= " + rt.MaxDuration,
+		Actual:   ctx.Duration.Round(time.Millisecond).String(),
+	}
+	max, err := rt.limit()
+	switch {
+	case err != nil:
+		res.Reason = fmt.Sprintf("Invalid response time limit '%s': %v", rt.MaxDuration, err)
+	case ctx.Duration > max:
+		res.Reason = fmt.Sprintf("Response time %v exceeded limit %v", ctx.Duration, max)
+	default:
+		res.IsHealthy = true
+	}
+	return res
+}
+
+// Leaf returns a node evaluated by ev, as if key had been used in YAML.
+func Leaf(key ConditionType, ev conditionevaluator.Evaluator) *Condition {
+	return &Condition{Evaluator: ev, EvaluatorKey: string(key)}
+}
+
+func RegexNode(r *RegexCondition) *Condition {
+	return Leaf(ConditionRegex, regexEvaluator{r})
+}
+
+func StatusCodeNode(s *StatusCodeCondition) *Condition {
+	return Leaf(ConditionStatusCode, statusCodeEvaluator{s})
+}
+
+func HeaderNode(headers ...HeaderCondition) *Condition {
+	return Leaf(ConditionHeader, headerEvaluator{&headers})
+}
+
+func ResponseTimeNode(rt *ResponseTimeCondition) *Condition {
+	return Leaf(ConditionResponseTime, responseTimeEvaluator{rt})
+}
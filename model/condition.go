@@ -4,8 +4,13 @@ import (
 	"fmt"
 	"net/http"
 	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
+	"gopkg.in/yaml.v3"
+
+	conditionevaluator "healthy-api/evaluator"
 )
 
 type ConditionType string
@@ -18,203 +23,521 @@ const (
 	ConditionOr         ConditionType = "or"
 	ConditionNot        ConditionType = "not"
 	ConditionResponseTime ConditionType = "response_time"
+	ConditionExpr         ConditionType = "expr"
+	ConditionJSONSchema   ConditionType = "json_schema"
+	ConditionCSSSelector  ConditionType = "css_selector"
+	ConditionXPath        ConditionType = "xpath"
+	ConditionTiming       ConditionType = "timing"
+	ConditionWindow       ConditionType = "window"
+	ConditionRef          ConditionType = "ref"
+	ConditionContentDrift ConditionType = "content_drift"
+	ConditionRedirect     ConditionType = "redirect"
 )
 
 type Condition struct {
 	And        []*Condition         `yaml:"and,omitempty"`
 	Or         []*Condition         `yaml:"or,omitempty"`
 	Not        *Condition           `yaml:"not,omitempty"`
-	Regex      *RegexCondition      `yaml:"regex,omitempty"`
-	StatusCode *StatusCodeCondition `yaml:"status_code,omitempty"`
-	Header     *[]HeaderCondition   `yaml:"header,omitempty"`
-	ResponseTime *ResponseTimeCondition `yaml:"response_time,omitempty"`
+	// Ref includes another named condition, optionally with Params
+	// overriding that definition's declared params.
+	Ref    string            `yaml:"ref,omitempty"`
+	Params map[string]string `yaml:"params,omitempty"`
+	// Severity tags the failures of this node, e.g. warning for a slow but
+	// correct response. Untagged nodes fail as critical.
+	Severity Severity `yaml:"severity,omitempty"`
+
+	resolved *Condition
+	// Evaluator is a node of a registered condition type (see package
+	// evaluator), built from the YAML key EvaluatorKey.
+	Evaluator    conditionevaluator.Evaluator `yaml:"-" json:"-"`
+	EvaluatorKey string                       `yaml:"-" json:"-"`
+
+	// line and column locate the node in the YAML source, 0 when built in Go.
+	line, column int
+	// decodeErrs are the problems found while decoding the node and refErr
+	// why its ref could not be resolved; Validate reports them.
+	decodeErrs []ValidationError
+	refErr     string
 }
 
 type NamedCondition struct {
-	ID        string     `yaml:"id"`
-	Condition *Condition `yaml:"condition"`
+	ID        string            `yaml:"id"`
+	Params    map[string]string `yaml:"params,omitempty"` // declared params and their defaults
+	Condition *Condition        `yaml:"condition"`
+
+	body *yaml.Node
 }
 
 type RegexCondition struct {
 	Regex string `yaml:"pattern"`
+
+	re *regexp.Regexp
 }
 
 type StatusCodeCondition struct {
-	Code int `yaml:"code"`
+	Code    int      `yaml:"code"`
+	Codes   []int    `yaml:"codes,omitempty"`
+	Range   string   `yaml:"range,omitempty"`   // e.g. "200-299"
+	Classes []string `yaml:"classes,omitempty"` // e.g. ["2xx", "3xx"]
+	Negate  bool     `yaml:"negate,omitempty"`
 }
 
+type HeaderOperator string
+
+const (
+	HeaderEquals   HeaderOperator = "equals"
+	HeaderContains HeaderOperator = "contains"
+	HeaderMatches  HeaderOperator = "matches"
+	HeaderExists   HeaderOperator = "exists"
+	HeaderAbsent   HeaderOperator = "absent"
+	HeaderGt       HeaderOperator = "gt"
+	HeaderGte      HeaderOperator = "gte"
+	HeaderLt       HeaderOperator = "lt"
+	HeaderLte      HeaderOperator = "lte"
+)
+
 type HeaderCondition struct {
-	Key   string `yaml:"key"`
-	Value string `yaml:"value"`
+	Key        string         `yaml:"key"`
+	Value      string         `yaml:"value"`
+	Operator   HeaderOperator `yaml:"operator,omitempty"` // defaults to equals
+	IgnoreCase bool           `yaml:"ignore_case,omitempty"`
+	// All requires every value of a multi-valued header to match instead of any one of them.
+	All bool `yaml:"all,omitempty"`
+
+	re  *regexp.Regexp
+	num *float64
 }
 type ResponseTimeCondition struct {
 	MaxDuration string `yaml:"max_duration"`
+
+	max *time.Duration
 }
 type EvaluationResult struct {
 	IsHealthy bool
 	Reason    string
+	// Severity of the failure, empty when healthy.
+	Severity Severity
+	// Trace is the per-node evaluation tree, rooted at the evaluated condition.
+	Trace *TraceNode
 }
 
-func (c *Condition) Validate(path string) error {
-	count := 0
-	if c.Regex != nil {
-		count++
-	}
-	if c.StatusCode != nil {
-		count++
-	}
-	if c.Header != nil {
-		count++
-	}
-	if c.And != nil {
-		count++
-	}
-	if c.Or != nil {
-		count++
-	}
-	if c.Not != nil {
-		count++
-	}
-	if c.ResponseTime != nil {
-		count++
-	}
-	if count != 1 {
-		return fmt.Errorf("a condition node must contain exactly one field (got %d) at %s", count, path)
-	}
-	if c.ResponseTime != nil {
-		if _, err := time.ParseDuration(c.ResponseTime.MaxDuration); err != nil {
-			return fmt.Errorf("invalid duration format '%s' at %s: %v", c.ResponseTime.MaxDuration, path, err)
-		}
-	}
-	for _, and := range c.And {
-		path = path + "." + "and"
-		if err := and.Validate(path); err != nil {
-			return err
-		}
+func (c *Condition) Evaluate(resp *http.Response, body []byte, duration time.Duration) EvaluationResult {
+	return c.EvaluateContext(&ResponseContext{Response: resp, Body: body, Duration: duration})
+}
+
+// EvaluateContext evaluates the condition tree against everything recorded
+// about one check.
+func (c *Condition) EvaluateContext(ctx *ResponseContext) EvaluationResult {
+	return c.evaluate(ctx, "condition")
+}
+
+// evaluate runs one node and attaches its trace, timed and labelled with
+// path.
+func (c *Condition) evaluate(ctx *ResponseContext, path string) EvaluationResult {
+	start := time.Now()
+	res := c.evaluateNode(ctx, path)
+	if res.Trace == nil {
+		res.Trace = &TraceNode{}
 	}
-	for _, or := range c.And {
-		path = path + "." + "or"
-		if err := or.Validate(path); err != nil {
-			return err
+	res.Trace.Type = c.Type()
+	res.Trace.Path = path
+	res.Trace.Passed = res.IsHealthy
+	res.Trace.Duration = time.Since(start)
+	if !res.IsHealthy {
+		if c.Severity != "" {
+			res.Severity = c.Severity
+		} else if res.Severity == "" {
+			res.Severity = SeverityCritical
 		}
+		res.Trace.Reason = res.Reason
+		res.Trace.Severity = res.Severity
 	}
-	return nil
+	return res
 }
-func (c *Condition) Evaluate(resp *http.Response, body []byte, duration time.Duration) EvaluationResult {
+
+func (c *Condition) evaluateNode(ctx *ResponseContext, path string) EvaluationResult {
 	// 1. منطق AND
+	// Warning failures do not stop the evaluation, so a later critical
+	// failure is not hidden behind them.
 	if c.And != nil {
-		for _, cond := range c.And {
-			res := cond.Evaluate(resp, body, duration)
+		trace := &TraceNode{}
+		var reasons []string
+		var severity Severity
+		for i, cond := range c.And {
+			childPath := fmt.Sprintf("%s.and[%d]", path, i)
+			if severity == SeverityCritical {
+				trace.Children = append(trace.Children, &TraceNode{Type: cond.Type(), Path: childPath, Skipped: true})
+				continue
+			}
+			res := cond.evaluate(ctx, childPath)
+			trace.Children = append(trace.Children, res.Trace)
 			if !res.IsHealthy {
-				return res
+				reasons = append(reasons, res.Reason)
+				severity = maxSeverity(severity, res.Severity)
 			}
 		}
-		return EvaluationResult{IsHealthy: true}
+		if len(reasons) > 0 {
+			return EvaluationResult{IsHealthy: false, Reason: strings.Join(reasons, "; "), Severity: severity, Trace: trace}
+		}
+		return EvaluationResult{IsHealthy: true, Trace: trace}
 	}
 
 	// 2. منطق OR
 	if c.Or != nil {
+		trace := &TraceNode{}
 		var reasons []string
+		var severity Severity
 		for i, cond := range c.Or {
-			res := cond.Evaluate(resp, body, duration)
+			res := cond.evaluate(ctx, fmt.Sprintf("%s.or[%d]", path, i))
+			trace.Children = append(trace.Children, res.Trace)
 			if res.IsHealthy {
-				return EvaluationResult{IsHealthy: true}
+				return EvaluationResult{IsHealthy: true, Trace: trace}
 			}
 			reasons = append(reasons, fmt.Sprintf("OR[%d]: %s", i, res.Reason))
+			severity = maxSeverity(severity, res.Severity)
 		}
 		return EvaluationResult{
 			IsHealthy: false,
-			Reason:    fmt.Sprintf("All OR conditions failed: %v", reasons),
+			Reason:    "All OR conditions failed: " + strings.Join(reasons, "; "),
+			Severity:  severity,
+			Trace:     trace,
 		}
 	}
 
 	// 3. منطق NOT
 	if c.Not != nil {
-    res := c.Not.Evaluate(resp, body, duration)
-    if res.IsHealthy {
-        return EvaluationResult{
-            IsHealthy: false,
-            Reason:    "Forbidden condition matched (Service should not have met this condition)",
-        }
-    }
-    return EvaluationResult{IsHealthy: true}
-}
-
-	// 4. بررسی Regex
-	if c.Regex != nil {
-		matched, _ := regexp.Match(c.Regex.Regex, body)
-		if !matched {
+		res := c.Not.evaluate(ctx, path+".not")
+		trace := &TraceNode{Children: []*TraceNode{res.Trace}}
+		if res.IsHealthy {
 			return EvaluationResult{
 				IsHealthy: false,
-				Reason:    fmt.Sprintf("Regex pattern '%s' not found in body", c.Regex.Regex),
+				Reason:    "Forbidden condition matched (Service should not have met this condition)",
+				Trace:     trace,
 			}
 		}
-		return EvaluationResult{IsHealthy: true}
+		return EvaluationResult{IsHealthy: true, Trace: trace}
 	}
 
-	// 5. بررسی StatusCode
-	if c.StatusCode != nil {
-		if resp == nil {
-			return EvaluationResult{IsHealthy: false, Reason: "No response received"}
+	// 4. شرط‌های ثبت‌شده (همه‌ی انواع برگ، از جمله انواع سفارشی)
+	if c.Evaluator != nil {
+		res := c.Evaluator.Evaluate(ctx)
+		if res.Reason == "" && !res.IsHealthy {
+			res.Reason = fmt.Sprintf("Condition '%s' failed", c.EvaluatorKey)
 		}
-		if resp.StatusCode != c.StatusCode.Code {
-			return EvaluationResult{
-				IsHealthy: false,
-				Reason:    fmt.Sprintf("Expected status %d, but got %d", c.StatusCode.Code, resp.StatusCode),
-			}
+		return EvaluationResult{
+			IsHealthy: res.IsHealthy,
+			Reason:    res.Reason,
+			Trace:     &TraceNode{Expected: res.Expected, Actual: res.Actual},
 		}
-		return EvaluationResult{IsHealthy: true}
 	}
 
-	// 6. بررسی Headers
-	if c.Header != nil {
-		if resp == nil {
-			return EvaluationResult{IsHealthy: false, Reason: "No response headers available"}
+	// 5. شرط ارجاعی
+	if c.Ref != "" {
+		if c.resolved == nil {
+			return EvaluationResult{IsHealthy: false, Reason: fmt.Sprintf("Condition ref '%s' is not resolved", c.Ref)}
 		}
-		for _, h := range *c.Header {
-			actual := resp.Header.Get(h.Key)
-			if actual != h.Value {
-				return EvaluationResult{
-					IsHealthy: false,
-					Reason:    fmt.Sprintf("Header '%s' expected '%s', got '%s'", h.Key, h.Value, actual),
-				}
-			}
+		res := c.resolved.evaluate(ctx, path+"->"+c.Ref)
+		return EvaluationResult{IsHealthy: res.IsHealthy, Reason: res.Reason, Severity: res.Severity, Trace: &TraceNode{Children: []*TraceNode{res.Trace}}}
+	}
+
+	return EvaluationResult{IsHealthy: false, Reason: "No valid condition defined"}
+}
+
+func (r *RegexCondition) Evaluate(body []byte) bool {
+	re, err := r.compile()
+	return err == nil && re.Match(body)
+}
+
+// compile returns the pattern compiled by Validate, compiling it on the fly
+// when Validate was not called.
+func (r *RegexCondition) compile() (*regexp.Regexp, error) {
+	if r.re != nil {
+		return r.re, nil
+	}
+	return regexp.Compile(r.Regex)
+}
+
+func (r *RegexCondition) Validate() error {
+	re, err := regexp.Compile(r.Regex)
+	if err != nil {
+		return fmt.Errorf("invalid pattern '%s': %v", r.Regex, err)
+	}
+	r.re = re
+	return nil
+}
+
+func (s *StatusCodeCondition) Evaluate(resp *http.Response) bool {
+	return resp != nil && s.Matches(resp.StatusCode)
+}
+
+// Matches reports whether code satisfies any of the configured codes, range
+// or classes, inverted when Negate is set.
+func (s *StatusCodeCondition) Matches(code int) bool {
+	matched := s.Code != 0 && code == s.Code
+	for _, c := range s.Codes {
+		if code == c {
+			matched = true
+		}
+	}
+	if s.Range != "" {
+		if low, high, err := parseStatusRange(s.Range); err == nil && code >= low && code <= high {
+			matched = true
+		}
+	}
+	for _, class := range s.Classes {
+		if low, high, err := parseStatusClass(class); err == nil && code >= low && code <= high {
+			matched = true
 		}
-		return EvaluationResult{IsHealthy: true}
 	}
+	return matched != s.Negate
+}
 
-	// 7. بررسی Response Time
-	if c.ResponseTime != nil {
-		max, _ := time.ParseDuration(c.ResponseTime.MaxDuration)
-		if duration > max {
-			return EvaluationResult{
-				IsHealthy: false,
-				Reason:    fmt.Sprintf("Response time %v exceeded limit %v", duration, max),
-			}
+func (s *StatusCodeCondition) Validate() error {
+	if s.Code == 0 && len(s.Codes) == 0 && s.Range == "" && len(s.Classes) == 0 {
+		return fmt.Errorf("one of code, codes, range or classes is required")
+	}
+	if s.Code != 0 {
+		if err := validateStatusCode(s.Code); err != nil {
+			return err
+		}
+	}
+	for _, c := range s.Codes {
+		if err := validateStatusCode(c); err != nil {
+			return err
 		}
-		return EvaluationResult{IsHealthy: true}
 	}
+	if s.Range != "" {
+		if _, _, err := parseStatusRange(s.Range); err != nil {
+			return err
+		}
+	}
+	for _, class := range s.Classes {
+		if _, _, err := parseStatusClass(class); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	return EvaluationResult{IsHealthy: false, Reason: "No valid condition defined"}
+func (s *StatusCodeCondition) String() string {
+	var parts []string
+	if s.Code != 0 {
+		parts = append(parts, strconv.Itoa(s.Code))
+	}
+	for _, c := range s.Codes {
+		parts = append(parts, strconv.Itoa(c))
+	}
+	if s.Range != "" {
+		parts = append(parts, s.Range)
+	}
+	parts = append(parts, s.Classes...)
+	desc := strings.Join(parts, ", ")
+	if len(parts) > 1 {
+		desc = "in [" + desc + "]"
+	}
+	if s.Negate {
+		return "not " + desc
+	}
+	return desc
 }
 
-func (r *RegexCondition) Evaluate(body []byte) bool {
-	matched, err := regexp.Match(r.Regex, body)
-	return err == nil && matched
+func validateStatusCode(code int) error {
+	if code < 100 || code > 599 {
+		return fmt.Errorf("status code %d is out of range 100-599", code)
+	}
+	return nil
 }
 
-func (s *StatusCodeCondition) Evaluate(resp *http.Response) bool {
+func parseStatusRange(r string) (int, int, error) {
+	lowStr, highStr, ok := strings.Cut(r, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("status range '%s' must look like 200-299", r)
+	}
+	low, err := strconv.Atoi(strings.TrimSpace(lowStr))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid status range '%s': %v", r, err)
+	}
+	high, err := strconv.Atoi(strings.TrimSpace(highStr))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid status range '%s': %v", r, err)
+	}
+	if err := validateStatusCode(low); err != nil {
+		return 0, 0, err
+	}
+	if err := validateStatusCode(high); err != nil {
+		return 0, 0, err
+	}
+	if low > high {
+		return 0, 0, fmt.Errorf("status range '%s' is empty", r)
+	}
+	return low, high, nil
+}
 
-	return resp.StatusCode == s.Code
+func parseStatusClass(class string) (int, int, error) {
+	c := strings.ToLower(strings.TrimSpace(class))
+	if len(c) != 3 || c[1:] != "xx" || c[0] < '1' || c[0] > '5' {
+		return 0, 0, fmt.Errorf("status class '%s' must be one of 1xx, 2xx, 3xx, 4xx, 5xx", class)
+	}
+	low := int(c[0]-'0') * 100
+	return low, low + 99, nil
 }
 
 func (h *HeaderCondition) Evaluate(resp *http.Response) bool {
+	if resp == nil {
+		return false
+	}
+	ok, _ := h.Check(resp.Header)
+	return ok
+}
 
-	return resp.Header.Get(h.Key) == h.Value
+func (h *HeaderCondition) operator() HeaderOperator {
+	if h.Operator == "" {
+		return HeaderEquals
+	}
+	return HeaderOperator(strings.ToLower(string(h.Operator)))
 }
 
-func (rt *ResponseTimeCondition) Evaluate(actual time.Duration) bool {
+func (h *HeaderCondition) Validate() error {
+	if h.Key == "" {
+		return fmt.Errorf("header key is required")
+	}
+	switch op := h.operator(); op {
+	case HeaderEquals, HeaderContains, HeaderExists, HeaderAbsent:
+	case HeaderMatches:
+		re, err := h.compile()
+		if err != nil {
+			return fmt.Errorf("invalid pattern '%s': %v", h.Value, err)
+		}
+		h.re = re
+	case HeaderGt, HeaderGte, HeaderLt, HeaderLte:
+		num, err := strconv.ParseFloat(h.Value, 64)
+		if err != nil {
+			return fmt.Errorf("operator '%s' needs a numeric value, got '%s'", op, h.Value)
+		}
+		h.num = &num
+	default:
+		return fmt.Errorf("unknown header operator '%s'", h.Operator)
+	}
+	return nil
+}
+
+func (h *HeaderCondition) compile() (*regexp.Regexp, error) {
+	if h.re != nil {
+		return h.re, nil
+	}
+	if h.IgnoreCase {
+		return regexp.Compile("(?i)" + h.Value)
+	}
+	return regexp.Compile(h.Value)
+}
+
+func (h *HeaderCondition) String() string {
+	op := h.operator()
+	if op == HeaderExists || op == HeaderAbsent {
+		return fmt.Sprintf("%s %s", http.CanonicalHeaderKey(h.Key), op)
+	}
+	return fmt.Sprintf("%s %s %s", http.CanonicalHeaderKey(h.Key), op, h.Value)
+}
+
+// Check evaluates the assertion against every value of the header and
+// returns a human readable reason when it does not hold.
+func (h *HeaderCondition) Check(header http.Header) (bool, string) {
+	values := header.Values(h.Key)
+	op := h.operator()
+	switch op {
+	case HeaderExists:
+		if len(values) == 0 {
+			return false, fmt.Sprintf("Header '%s' expected to exist", h.Key)
+		}
+		return true, ""
+	case HeaderAbsent:
+		if len(values) > 0 {
+			return false, fmt.Sprintf("Header '%s' expected to be absent, got '%s'", h.Key, strings.Join(values, ", "))
+		}
+		return true, ""
+	}
+	if len(values) == 0 {
+		return false, fmt.Sprintf("Header '%s' expected %s '%s', but it is missing", h.Key, op, h.Value)
+	}
+
+	var re *regexp.Regexp
+	if op == HeaderMatches {
+		var err error
+		if re, err = h.compile(); err != nil {
+			return false, fmt.Sprintf("Header '%s' has invalid pattern '%s': %v", h.Key, h.Value, err)
+		}
+	}
+	matched := 0
+	for _, v := range values {
+		if h.matchValue(op, v, re) {
+			matched++
+		}
+	}
+	if (h.All && matched == len(values)) || (!h.All && matched > 0) {
+		return true, ""
+	}
+	actual := strings.Join(values, ", ")
+	if op == HeaderEquals {
+		return false, fmt.Sprintf("Header '%s' expected '%s', got '%s'", h.Key, h.Value, actual)
+	}
+	return false, fmt.Sprintf("Header '%s' expected %s '%s', got '%s'", h.Key, op, h.Value, actual)
+}
+
+func (h *HeaderCondition) matchValue(op HeaderOperator, actual string, re *regexp.Regexp) bool {
+	expected := h.Value
+	if h.IgnoreCase {
+		actual, expected = strings.ToLower(actual), strings.ToLower(expected)
+	}
+	switch op {
+	case HeaderEquals:
+		return actual == expected
+	case HeaderContains:
+		return strings.Contains(actual, expected)
+	case HeaderMatches:
+		return re.MatchString(actual)
+	case HeaderGt, HeaderGte, HeaderLt, HeaderLte:
+		a, err := strconv.ParseFloat(strings.TrimSpace(actual), 64)
+		if err != nil {
+			return false
+		}
+		var e float64
+		if h.num != nil {
+			e = *h.num
+		} else if e, err = strconv.ParseFloat(expected, 64); err != nil {
+			return false
+		}
+		switch op {
+		case HeaderGt:
+			return a > e
+		case HeaderGte:
+			return a >= e
+		case HeaderLt:
+			return a < e
+		default:
+			return a <= e
+		}
+	}
+	return false
+}
+
+func (rt *ResponseTimeCondition) Validate() error {
 	max, err := time.ParseDuration(rt.MaxDuration)
+	if err != nil {
+		return fmt.Errorf("invalid duration format '%s': %v", rt.MaxDuration, err)
+	}
+	rt.max = &max
+	return nil
+}
+
+func (rt *ResponseTimeCondition) limit() (time.Duration, error) {
+	if rt.max != nil {
+		return *rt.max, nil
+	}
+	return time.ParseDuration(rt.MaxDuration)
+}
+
+func (rt *ResponseTimeCondition) Evaluate(actual time.Duration) bool {
+	max, err := rt.limit()
 	if err != nil {
 		return false 
 	}
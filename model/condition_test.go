@@ -1,12 +1,17 @@
 package model_test
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"healthy-api/model"
 	"io"
 	"net/http"
 	"strings"
 	"testing"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 // تابع کمکی برای شبیه‌سازی پاسخ HTTP
@@ -17,18 +22,22 @@ func newMockResponse(statusCode int, body string) *http.Response {
 	}
 }
 
+// leaf builds a node of a registered condition type in Go.
+func withSeverity(c *model.Condition, s model.Severity) *model.Condition {
+	c.Severity = s
+	return c
+}
+
 func TestCondition_EvaluateComplexNested(t *testing.T) {
 	complexCondition := &model.Condition{
 		Or: []*model.Condition{
 			{
 				And: []*model.Condition{
-					{StatusCode: &model.StatusCodeCondition{Code: 200}},
-					{Regex: &model.RegexCondition{Regex: "OK"}},
+					model.StatusCodeNode(&model.StatusCodeCondition{Code: 200}),
+					model.RegexNode(&model.RegexCondition{Regex: "OK"}),
 				},
 			},
-			{
-				StatusCode: &model.StatusCodeCondition{Code: 404},
-			},
+			model.StatusCodeNode(&model.StatusCodeCondition{Code: 404}),
 		},
 	}
 
@@ -63,11 +72,9 @@ func TestCondition_EvaluateComplexNested(t *testing.T) {
 }
 
 func TestResponseTimeCondition(t *testing.T) {
-	cond := &model.Condition{
-		ResponseTime: &model.ResponseTimeCondition{
-			MaxDuration: "500ms",
-		},
-	}
+	cond := model.ResponseTimeNode(&model.ResponseTimeCondition{
+		MaxDuration: "500ms",
+	})
 
 	// حالت موفق
 	resultOk := cond.Evaluate(nil, nil, 200*time.Millisecond)
@@ -85,8 +92,8 @@ func TestResponseTimeCondition(t *testing.T) {
 func TestCombinedCondition(t *testing.T) {
 	cond := &model.Condition{
 		And: []*model.Condition{
-			{StatusCode: &model.StatusCodeCondition{Code: 200}},
-			{ResponseTime: &model.ResponseTimeCondition{MaxDuration: "500ms"}},
+			model.StatusCodeNode(&model.StatusCodeCondition{Code: 200}),
+			model.ResponseTimeNode(&model.ResponseTimeCondition{MaxDuration: "500ms"}),
 		},
 	}
 
@@ -113,23 +120,800 @@ func TestCombinedCondition(t *testing.T) {
 }
 
 func TestValidation(t *testing.T) {
-	invalidCond := &model.Condition{
-		ResponseTime: &model.ResponseTimeCondition{
-			MaxDuration: "invalid-time",
-		},
-	}
+	invalidCond := model.ResponseTimeNode(&model.ResponseTimeCondition{
+		MaxDuration: "invalid-time",
+	})
 
 	if err := invalidCond.Validate("test"); err == nil {
 		t.Error("Validation should fail for invalid duration format")
 	}
 
-	validCond := &model.Condition{
-		ResponseTime: &model.ResponseTimeCondition{
-			MaxDuration: "1.5s",
-		},
-	}
+	validCond := model.ResponseTimeNode(&model.ResponseTimeCondition{
+		MaxDuration: "1.5s",
+	})
 
 	if err := validCond.Validate("test"); err != nil {
 		t.Errorf("Validation should pass for '1.5s', got: %v", err)
 	}
-}
\ No newline at end of file
+}
+func TestStatusCodeCondition_Matches(t *testing.T) {
+	cases := []struct {
+		name string
+		cond model.StatusCodeCondition
+		code int
+		want bool
+	}{
+		{"exact", model.StatusCodeCondition{Code: 200}, 200, true},
+		{"list", model.StatusCodeCondition{Codes: []int{200, 204}}, 204, true},
+		{"list miss", model.StatusCodeCondition{Codes: []int{200, 204}}, 201, false},
+		{"range", model.StatusCodeCondition{Range: "200-299"}, 250, true},
+		{"range miss", model.StatusCodeCondition{Range: "200-299"}, 301, false},
+		{"classes", model.StatusCodeCondition{Classes: []string{"2xx", "3XX"}}, 302, true},
+		{"classes miss", model.StatusCodeCondition{Classes: []string{"2xx", "3xx"}}, 404, false},
+		{"negated", model.StatusCodeCondition{Classes: []string{"5xx"}, Negate: true}, 503, false},
+		{"negated pass", model.StatusCodeCondition{Classes: []string{"5xx"}, Negate: true}, 200, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.cond.Matches(tc.code); got != tc.want {
+				t.Errorf("Matches(%d) = %v, want %v", tc.code, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestStatusCodeCondition_Validate(t *testing.T) {
+	invalid := []model.StatusCodeCondition{
+		{},
+		{Code: 999},
+		{Codes: []int{200, 42}},
+		{Range: "300-200"},
+		{Range: "200"},
+		{Classes: []string{"6xx"}},
+	}
+	for _, s := range invalid {
+		cond := model.StatusCodeNode(&s)
+		if err := cond.Validate("test"); err == nil {
+			t.Errorf("Validation should fail for %+v", s)
+		}
+	}
+
+	valid := model.StatusCodeNode(&model.StatusCodeCondition{Range: "200-399", Codes: []int{418}})
+	if err := valid.Validate("test"); err != nil {
+		t.Errorf("Validation should pass, got: %v", err)
+	}
+}
+
+func TestHeaderCondition_Check(t *testing.T) {
+	header := http.Header{}
+	header.Set("Content-Type", "application/json; charset=utf-8")
+	header.Set("X-RateLimit-Remaining", "42")
+	header.Add("Cache-Control", "no-cache")
+	header.Add("Cache-Control", "private")
+
+	cases := []struct {
+		name string
+		cond model.HeaderCondition
+		want bool
+	}{
+		{"equals with charset fails", model.HeaderCondition{Key: "Content-Type", Value: "application/json"}, false},
+		{"contains", model.HeaderCondition{Key: "Content-Type", Value: "application/json", Operator: model.HeaderContains}, true},
+		{"matches", model.HeaderCondition{Key: "content-type", Value: `^application/json(;|$)`, Operator: model.HeaderMatches}, true},
+		{"ignore case", model.HeaderCondition{Key: "Content-Type", Value: "APPLICATION/JSON; CHARSET=UTF-8", IgnoreCase: true}, true},
+		{"exists", model.HeaderCondition{Key: "X-RateLimit-Remaining", Operator: model.HeaderExists}, true},
+		{"absent", model.HeaderCondition{Key: "X-Debug", Operator: model.HeaderAbsent}, true},
+		{"absent fails", model.HeaderCondition{Key: "Content-Type", Operator: model.HeaderAbsent}, false},
+		{"gt", model.HeaderCondition{Key: "X-RateLimit-Remaining", Value: "10", Operator: model.HeaderGt}, true},
+		{"lt fails", model.HeaderCondition{Key: "X-RateLimit-Remaining", Value: "10", Operator: model.HeaderLt}, false},
+		{"missing header", model.HeaderCondition{Key: "Age", Value: "10", Operator: model.HeaderLt}, false},
+		{"multi-valued any", model.HeaderCondition{Key: "Cache-Control", Value: "private"}, true},
+		{"multi-valued all", model.HeaderCondition{Key: "Cache-Control", Value: "private", All: true}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.cond.Validate(); err != nil {
+				t.Fatalf("unexpected validation error: %v", err)
+			}
+			got, reason := tc.cond.Check(header)
+			if got != tc.want {
+				t.Errorf("Check() = %v, want %v (reason: %s)", got, tc.want, reason)
+			}
+		})
+	}
+
+	invalid := []model.HeaderCondition{
+		{Value: "x"},
+		{Key: "Age", Value: "abc", Operator: model.HeaderGt},
+		{Key: "Age", Value: "(", Operator: model.HeaderMatches},
+		{Key: "Age", Operator: "near"},
+	}
+	for _, h := range invalid {
+		if err := h.Validate(); err == nil {
+			t.Errorf("Validation should fail for %+v", h)
+		}
+	}
+}
+
+func TestExpressionCondition(t *testing.T) {
+	var cond model.Condition
+	src := `expr: 'status in [200, 204] && json.queue.depth < 1000 && duration < 500ms && headers["content-type"] == "application/json"'`
+	if err := yaml.Unmarshal([]byte(src), &cond); err != nil {
+		t.Fatalf("failed to parse YAML: %v", err)
+	}
+	if err := cond.Validate("test"); err != nil {
+		t.Fatalf("Validation should pass, got: %v", err)
+	}
+
+	resp := newMockResponse(200, "")
+	resp.Header = http.Header{"Content-Type": []string{"application/json"}}
+
+	res := cond.Evaluate(resp, []byte(`{"queue": {"depth": 10}}`), 100*time.Millisecond)
+	if !res.IsHealthy {
+		t.Errorf("Should be healthy, but got: %s", res.Reason)
+	}
+
+	res = cond.Evaluate(resp, []byte(`{"queue": {"depth": 5000}}`), 100*time.Millisecond)
+	if res.IsHealthy {
+		t.Error("Should fail because the queue is too deep")
+	}
+
+	res = cond.Evaluate(resp, []byte(`not json`), 100*time.Millisecond)
+	if res.IsHealthy {
+		t.Error("Should fail because the body is not JSON")
+	}
+
+	invalid := model.Leaf(model.ConditionExpr, &model.ExpressionCondition{Source: "status == '200'"})
+	if err := invalid.Validate("test"); err == nil {
+		t.Error("Validation should fail for a type mismatch")
+	}
+}
+
+func TestJSONSchemaCondition(t *testing.T) {
+	var cond model.Condition
+	src := `
+json_schema:
+  schema:
+    type: object
+    required: [id]
+    properties:
+      id:
+        type: integer
+`
+	if err := yaml.Unmarshal([]byte(src), &cond); err != nil {
+		t.Fatalf("failed to parse YAML: %v", err)
+	}
+	if err := cond.Validate("test"); err != nil {
+		t.Fatalf("Validation should pass, got: %v", err)
+	}
+
+	res := cond.Evaluate(newMockResponse(200, ""), []byte(`{"id": 7}`), 0)
+	if !res.IsHealthy {
+		t.Errorf("Should be healthy, but got: %s", res.Reason)
+	}
+
+	res = cond.Evaluate(newMockResponse(200, ""), []byte(`{"id": "7"}`), 0)
+	if res.IsHealthy {
+		t.Fatal("Should fail because id changed type")
+	}
+	if !strings.Contains(res.Reason, "/id") || !strings.Contains(res.Reason, "'type'") {
+		t.Errorf("Reason should name the pointer and keyword, got: %s", res.Reason)
+	}
+
+	missing := model.Leaf(model.ConditionJSONSchema, &model.JSONSchemaCondition{File: "does-not-exist.json"})
+	if err := missing.Validate("test"); err == nil {
+		t.Error("Validation should fail for a missing schema file")
+	}
+}
+
+func TestMarkupConditions(t *testing.T) {
+	html := []byte(`<html><body><div id="status" data-state="up">  UP </div><li class="node">a<li class="node">b</body></html>`)
+	xml := []byte(`<Envelope><Body><Price currency="USD">34.5</Price></Body></Envelope>`)
+	two, one := 2, 1
+
+	cases := []struct {
+		name string
+		cond *model.Condition
+		body []byte
+		want bool
+	}{
+		{"css exists", model.Leaf(model.ConditionCSSSelector, &model.CSSSelectorCondition{Selector: "#status"}), html, true},
+		{"css text", model.Leaf(model.ConditionCSSSelector, &model.CSSSelectorCondition{Selector: "#status", MarkupAssertion: model.MarkupAssertion{Text: "UP"}}), html, true},
+		{"css attribute", model.Leaf(model.ConditionCSSSelector, &model.CSSSelectorCondition{Selector: "div", MarkupAssertion: model.MarkupAssertion{Attribute: "data-state", Pattern: "^(up|degraded)$"}}), html, true},
+		{"css count", model.Leaf(model.ConditionCSSSelector, &model.CSSSelectorCondition{Selector: "li.node", MarkupAssertion: model.MarkupAssertion{MinCount: &two, MaxCount: &two}}), html, true},
+		{"css too many", model.Leaf(model.ConditionCSSSelector, &model.CSSSelectorCondition{Selector: "li.node", MarkupAssertion: model.MarkupAssertion{MaxCount: &one}}), html, false},
+		{"css missing", model.Leaf(model.ConditionCSSSelector, &model.CSSSelectorCondition{Selector: ".error"}), html, false},
+		{"xpath text", model.Leaf(model.ConditionXPath, &model.XPathCondition{Path: "/Envelope/Body/Price", MarkupAssertion: model.MarkupAssertion{Pattern: `^\d+\.\d+$`}}), xml, true},
+		{"xpath attribute", model.Leaf(model.ConditionXPath, &model.XPathCondition{Path: "//Price/@currency", MarkupAssertion: model.MarkupAssertion{Text: "EUR"}}), xml, false},
+		{"xpath bad xml", model.Leaf(model.ConditionXPath, &model.XPathCondition{Path: "//Price"}), []byte("<a><b></a>"), false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.cond.Validate("test"); err != nil {
+				t.Fatalf("unexpected validation error: %v", err)
+			}
+			res := tc.cond.Evaluate(newMockResponse(200, ""), tc.body, 0)
+			if res.IsHealthy != tc.want {
+				t.Errorf("IsHealthy = %v, want %v (reason: %s)", res.IsHealthy, tc.want, res.Reason)
+			}
+		})
+	}
+
+	invalid := model.Leaf(model.ConditionXPath, &model.XPathCondition{Path: "//Price["})
+	if err := invalid.Validate("test"); err == nil {
+		t.Error("Validation should fail for a broken xpath")
+	}
+}
+
+func TestTimingCondition(t *testing.T) {
+	cond := model.Leaf(model.ConditionTiming, &model.TimingCondition{DNS: "50ms", TTFB: "300ms"})
+	if err := cond.Validate("test"); err != nil {
+		t.Fatalf("Validation should pass, got: %v", err)
+	}
+
+	ok := cond.EvaluateContext(&model.ResponseContext{Timing: model.Timing{DNS: 10 * time.Millisecond, TTFB: 200 * time.Millisecond, Download: time.Second}})
+	if !ok.IsHealthy {
+		t.Errorf("Should be healthy, but got: %s", ok.Reason)
+	}
+
+	slowDNS := cond.EvaluateContext(&model.ResponseContext{Timing: model.Timing{DNS: 80 * time.Millisecond}})
+	if slowDNS.IsHealthy || !strings.Contains(slowDNS.Reason, "DNS lookup") {
+		t.Errorf("Should fail on DNS, got: %+v", slowDNS)
+	}
+
+	for _, invalid := range []*model.TimingCondition{{}, {TLS: "fast"}} {
+		if err := model.Leaf(model.ConditionTiming, invalid).Validate("test"); err == nil {
+			t.Errorf("Validation should fail for %+v", invalid)
+		}
+	}
+}
+
+func TestWindowCondition(t *testing.T) {
+	now := time.Now()
+	history := model.NewHistory(20, 0)
+	for i := 0; i < 20; i++ {
+		d := 100 * time.Millisecond
+		if i%10 == 0 {
+			d = 2 * time.Second
+		}
+		history.Add(model.CheckSample{Time: now.Add(time.Duration(i-20) * time.Second), Duration: d, StatusCode: 200, Success: i%5 != 0})
+	}
+
+	cases := []struct {
+		name   string
+		window model.WindowCondition
+		want   bool
+	}{
+		{"p50 fast", model.WindowCondition{Metric: "p50_response_time", Operator: "<", Value: "800ms", Checks: 20}, true},
+		{"p95 slow", model.WindowCondition{Metric: "p95_response_time", Operator: "<", Value: "800ms", Checks: 20}, false},
+		{"avg", model.WindowCondition{Metric: "avg_response_time", Operator: "<=", Value: "300ms", Checks: 20}, true},
+		{"success rate", model.WindowCondition{Metric: "success_rate", Operator: ">=", Value: "95%", Period: "10m"}, false},
+		{"success rate lenient", model.WindowCondition{Metric: "success_rate", Operator: ">=", Value: "80", Period: "10m"}, true},
+		{"error count", model.WindowCondition{Metric: "error_count", Operator: "<=", Value: "2", Checks: 15}, false},
+		{"error count small window", model.WindowCondition{Metric: "error_count", Operator: "<=", Value: "2", Checks: 5}, true},
+		{"not enough samples", model.WindowCondition{Metric: "error_count", Operator: "==", Value: "0", Checks: 20, MinSamples: 30}, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cond := model.Leaf(model.ConditionWindow, &tc.window)
+			if err := cond.Validate("test"); err != nil {
+				t.Fatalf("unexpected validation error: %v", err)
+			}
+			res := cond.EvaluateContext(&model.ResponseContext{History: history})
+			if res.IsHealthy != tc.want {
+				t.Errorf("IsHealthy = %v, want %v (reason: %s)", res.IsHealthy, tc.want, res.Reason)
+			}
+			if base := cond.EvaluateContext(&model.ResponseContext{}); !base.IsHealthy {
+				t.Error("window conditions should pass without history")
+			}
+		})
+	}
+
+	invalid := []model.WindowCondition{
+		{Metric: "p95_response_time", Operator: "<", Value: "fast", Checks: 5},
+		{Metric: "p0_response_time", Operator: "<", Value: "1s", Checks: 5},
+		{Metric: "success_rate", Operator: ">=", Value: "120%", Checks: 5},
+		{Metric: "error_count", Operator: "~", Value: "1", Checks: 5},
+		{Metric: "error_count", Operator: "<", Value: "1"},
+		{Metric: "latency", Operator: "<", Value: "1", Checks: 5},
+	}
+	for _, w := range invalid {
+		if err := model.Leaf(model.ConditionWindow, &w).Validate("test"); err == nil {
+			t.Errorf("Validation should fail for %+v", w)
+		}
+	}
+}
+
+func TestHistoryPruning(t *testing.T) {
+	now := time.Now()
+	history := model.NewHistory(3, time.Minute)
+	for i := 10; i >= 0; i-- {
+		history.Add(model.CheckSample{Time: now.Add(-time.Duration(i) * 30 * time.Second)})
+	}
+	// The last 3 checks and everything from the last minute are kept.
+	if got := len(history.Window(0, 0, now)); got != 3 {
+		t.Errorf("expected 3 samples after pruning, got %d", got)
+	}
+	if got := len(history.Window(2, 0, now)); got != 2 {
+		t.Errorf("expected 2 samples in a 2-check window, got %d", got)
+	}
+}
+
+func TestConditionRefs(t *testing.T) {
+	src := `
+- id: fast
+  params:
+    max: 300ms
+  condition:
+    response_time:
+      max_duration: ${max}
+- id: ok-and-fast
+  params:
+    code: 200
+    max: 300ms
+  condition:
+    and:
+      - status_code: {code: "${code}"}
+      - ref: fast
+        params: {max: "${max}"}
+- id: loop-a
+  condition: {ref: loop-b}
+- id: loop-b
+  condition: {ref: loop-a}
+`
+	var named []model.NamedCondition
+	if err := yaml.Unmarshal([]byte(src), &named); err != nil {
+		t.Fatalf("failed to parse YAML: %v", err)
+	}
+	set := model.NewConditionSet(named)
+
+	for _, id := range []string{"fast", "ok-and-fast"} {
+		if err := set.Resolve(id, named[indexOf(named, id)].Condition); err != nil {
+			t.Fatalf("resolving %s: %v", id, err)
+		}
+	}
+	if err := set.Resolve("loop-a", named[indexOf(named, "loop-a")].Condition); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("expected a reference cycle error, got %v", err)
+	}
+
+	check := func(c *model.Condition, code int, d time.Duration) bool {
+		return c.Evaluate(newMockResponse(code, ""), nil, d).IsHealthy
+	}
+
+	slow := &model.Condition{Ref: "ok-and-fast", Params: map[string]string{"code": "204", "max": "2s"}}
+	if err := set.Resolve("", slow); err != nil {
+		t.Fatalf("resolving inline ref: %v", err)
+	}
+	if err := slow.Validate("test"); err != nil {
+		t.Fatalf("Validation should pass, got: %v", err)
+	}
+	if !check(slow, 204, time.Second) {
+		t.Error("204 within 2s should pass with overridden params")
+	}
+	if check(slow, 200, time.Second) {
+		t.Error("200 should fail when the code param is 204")
+	}
+	res := slow.Evaluate(newMockResponse(204, ""), nil, 3*time.Second)
+	fastNode := res.Trace.Children[0].Children[1].Children[0]
+	if fastNode.Path != "condition->ok-and-fast.and[1]->fast" || fastNode.Passed {
+		t.Errorf("unexpected trace node for the nested ref: %+v", fastNode)
+	}
+
+	defaults := named[indexOf(named, "ok-and-fast")].Condition
+	if check(defaults, 200, time.Second) {
+		t.Error("1s should fail against the default 300ms limit")
+	}
+	if !check(defaults, 200, 100*time.Millisecond) {
+		t.Error("100ms should pass against the default 300ms limit")
+	}
+
+	if err := set.Resolve("", &model.Condition{Ref: "missing"}); err == nil {
+		t.Error("expected an error for an unknown ref")
+	}
+	if err := set.Resolve("", &model.Condition{Ref: "fast", Params: map[string]string{"min": "1s"}}); err == nil {
+		t.Error("expected an error for an undeclared param")
+	}
+	if err := (&model.Condition{Ref: "fast"}).Validate("test"); err == nil {
+		t.Error("Validation should fail for an unresolved ref")
+	}
+}
+
+func indexOf(named []model.NamedCondition, id string) int {
+	for i, n := range named {
+		if n.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestEvaluationTrace(t *testing.T) {
+	cond := &model.Condition{
+		And: []*model.Condition{
+			model.StatusCodeNode(&model.StatusCodeCondition{Code: 200}),
+			{Or: []*model.Condition{
+				model.RegexNode(&model.RegexCondition{Regex: "OK"}),
+				{Not: model.ResponseTimeNode(&model.ResponseTimeCondition{MaxDuration: "1s"})},
+			}},
+			model.ResponseTimeNode(&model.ResponseTimeCondition{MaxDuration: "1s"}),
+		},
+	}
+
+	res := cond.Evaluate(newMockResponse(200, ""), []byte("Error"), 100*time.Millisecond)
+	if res.IsHealthy {
+		t.Fatal("Should fail because the OR fails")
+	}
+	root := res.Trace
+	if root == nil || root.Type != model.ConditionAnd || root.Path != "condition" || root.Passed {
+		t.Fatalf("unexpected root trace: %+v", root)
+	}
+	if len(root.Children) != 3 {
+		t.Fatalf("expected 3 children, got %d", len(root.Children))
+	}
+	status := root.Children[0]
+	if !status.Passed || status.Expected != "200" || status.Actual != "200" {
+		t.Errorf("unexpected status_code trace: %+v", status)
+	}
+	or := root.Children[1]
+	if or.Passed || len(or.Children) != 2 || or.Children[1].Children[0].Path != "condition.and[1].or[1].not" {
+		t.Errorf("unexpected or trace: %+v", or)
+	}
+	if !root.Children[2].Skipped {
+		t.Error("the node after the failing OR should be skipped")
+	}
+
+	text := root.String()
+	for _, want := range []string{
+		"FAIL and condition",
+		"  PASS status_code condition.and[0]: expected 200, actual 200",
+		"    FAIL regex condition.and[1].or[0]: expected body matching /OK/, actual 5 bytes",
+		"  SKIP response_time condition.and[2]",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("rendered trace is missing %q:\n%s", want, text)
+		}
+	}
+	if !strings.Contains(res.Reason, "OR[0]: Regex pattern 'OK' not found in body; OR[1]:") {
+		t.Errorf("unexpected reason: %s", res.Reason)
+	}
+
+	var decoded model.TraceNode
+	if err := json.Unmarshal([]byte(root.JSON()), &decoded); err != nil {
+		t.Fatalf("trace JSON does not decode: %v", err)
+	}
+	if decoded.Children[1].Children[0].Reason == "" {
+		t.Error("failed leaves should carry their reason in JSON")
+	}
+
+	data, err := json.Marshal(cond)
+	if err != nil {
+		t.Fatalf("condition does not encode: %v", err)
+	}
+	if strings.Contains(string(data), "Evaluator") {
+		t.Errorf("evaluator fields should stay out of JSON: %s", data)
+	}
+}
+
+func TestValidation_CollectsAllErrorsWithPositions(t *testing.T) {
+	src := `and:
+  - status_code: {code: 200}
+  - or:
+      - regex: {pattern: "("}
+      - response_time: {max_duration: "soon"}
+  - not:
+      header:
+        - {key: X-Retry, operator: gt, value: many}
+`
+	var cond model.Condition
+	if err := yaml.Unmarshal([]byte(src), &cond); err != nil {
+		t.Fatalf("failed to parse YAML: %v", err)
+	}
+	err := cond.Validate("conditions.api")
+	var errs model.ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected ValidationErrors, got %v", err)
+	}
+	if len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got %d:\n%v", len(errs), err)
+	}
+	want := []struct {
+		line int
+		path string
+	}{
+		{4, "conditions.api.and[1].or[0]"},
+		{5, "conditions.api.and[1].or[1]"},
+		{7, "conditions.api.and[2].not"},
+	}
+	for i, w := range want {
+		if errs[i].Line != w.line || errs[i].Path != w.path {
+			t.Errorf("error %d: got line %d path %s, want line %d path %s", i, errs[i].Line, errs[i].Path, w.line, w.path)
+		}
+	}
+	if msg := errs.InFile("config.yaml")[0].Error(); !strings.HasPrefix(msg, "config.yaml:4:9: conditions.api.and[1].or[0]: invalid regex") {
+		t.Errorf("unexpected message: %s", msg)
+	}
+}
+
+func TestValidation_ReportsDecodeAndRefErrors(t *testing.T) {
+	src := `and:
+  - status_code: {code: [200]}
+  - regex: {pattern: OK}
+    status_code: {code: 200}
+  - ref: missing
+  - or: 5
+  - response_time: {max_duration: "soon"}
+`
+	var cond model.Condition
+	if err := yaml.Unmarshal([]byte(src), &cond); err != nil {
+		t.Fatalf("decoding should not stop at the first bad node, got: %v", err)
+	}
+	if err := model.NewConditionSet(nil).Resolve("", &cond); err == nil {
+		t.Error("expected an error for an unknown ref")
+	}
+	err := cond.Validate("conditions.api")
+	var errs model.ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected ValidationErrors, got %v", err)
+	}
+	want := []struct {
+		line int
+		path string
+		msg  string
+	}{
+		{2, "conditions.api.and[0]", "status_code:"},
+		{4, "conditions.api.and[1]", "got both regex and status_code"},
+		{5, "conditions.api.and[2]", "unknown condition ref 'missing'"},
+		{6, "conditions.api.and[3]", "cannot unmarshal"},
+		{7, "conditions.api.and[4]", "invalid response_time"},
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("expected %d errors, got %d:\n%v", len(want), len(errs), err)
+	}
+	for i, w := range want {
+		if errs[i].Line != w.line || errs[i].Path != w.path || !strings.Contains(errs[i].Msg, w.msg) {
+			t.Errorf("error %d: got %+v, want line %d path %s containing %q", i, errs[i], w.line, w.path, w.msg)
+		}
+	}
+	if msg := errs.InFile("config.yaml")[3].Error(); !strings.HasPrefix(msg, "config.yaml:6: conditions.api.and[3]: cannot unmarshal") {
+		t.Errorf("unexpected message: %s", msg)
+	}
+}
+
+func TestConditionSeverity(t *testing.T) {
+	src := `and:
+  - status_code: {classes: ["2xx"]}
+  - response_time: {max_duration: 500ms}
+    severity: warning
+  - or:
+      - regex: {pattern: OK}
+      - regex: {pattern: MAINTENANCE}
+        severity: warning
+`
+	var cond model.Condition
+	if err := yaml.Unmarshal([]byte(src), &cond); err != nil {
+		t.Fatalf("failed to parse YAML: %v", err)
+	}
+	if err := cond.Validate("test"); err != nil {
+		t.Fatalf("Validation should pass, got: %v", err)
+	}
+
+	cases := []struct {
+		name     string
+		code     int
+		body     string
+		duration time.Duration
+		state    model.ServiceState
+		severity model.Severity
+	}{
+		{"healthy", 200, "OK", 100 * time.Millisecond, model.StateUp, ""},
+		{"slow", 200, "OK", time.Second, model.StateDegraded, model.SeverityWarning},
+		{"or fails with a critical branch", 200, "busy", 100 * time.Millisecond, model.StateDown, model.SeverityCritical},
+		{"slow and server error", 500, "OK", time.Second, model.StateDown, model.SeverityCritical},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			res := cond.Evaluate(newMockResponse(tc.code, tc.body), []byte(tc.body), tc.duration)
+			if res.State() != tc.state || res.Severity != tc.severity {
+				t.Errorf("got %s/%q, want %s/%q (reason: %s)", res.State(), res.Severity, tc.state, tc.severity, res.Reason)
+			}
+		})
+	}
+
+	warnings := &model.Condition{Or: []*model.Condition{
+		withSeverity(model.RegexNode(&model.RegexCondition{Regex: "OK"}), model.SeverityWarning),
+		withSeverity(model.RegexNode(&model.RegexCondition{Regex: "MAINTENANCE"}), model.SeverityWarning),
+	}}
+	if res := warnings.Evaluate(newMockResponse(200, "busy"), []byte("busy"), time.Millisecond); res.State() != model.StateDegraded {
+		t.Errorf("an or whose failed branches are all warnings should degrade, got %s", res.State())
+	}
+
+	target := model.Target{Severities: []model.Severity{model.SeverityWarning}}
+	if !target.Accepts(model.SeverityWarning) || target.Accepts(model.SeverityCritical) {
+		t.Error("target should only accept warnings")
+	}
+	if !(model.Target{}).Accepts(model.SeverityCritical) {
+		t.Error("a target without severities should accept everything")
+	}
+
+	invalid := withSeverity(model.StatusCodeNode(&model.StatusCodeCondition{Code: 200}), "minor")
+	if err := invalid.Validate("test"); err == nil {
+		t.Error("Validation should fail for an unknown severity")
+	}
+}
+
+func TestContentDriftCondition(t *testing.T) {
+	src := `content_drift:
+  ignore: ['csrf="[^"]*"']
+`
+	var cond model.Condition
+	if err := yaml.Unmarshal([]byte(src), &cond); err != nil {
+		t.Fatalf("failed to parse YAML: %v", err)
+	}
+	if err := cond.Validate("test"); err != nil {
+		t.Fatalf("Validation should pass, got: %v", err)
+	}
+	store := model.NewBaselineStore()
+	check := func(body string) model.EvaluationResult {
+		return cond.EvaluateContext(&model.ResponseContext{Response: newMockResponse(200, body), Body: []byte(body), Baselines: store})
+	}
+
+	page := "<h1>Welcome</h1>\n<form csrf=\"%s\">\n<p>Prices</p>\n<p>About</p>\n"
+	if res := check(fmt.Sprintf(page, "abc")); !res.IsHealthy {
+		t.Fatalf("the first body becomes the baseline, got: %s", res.Reason)
+	}
+	if res := check(fmt.Sprintf(page, "xyz")); !res.IsHealthy {
+		t.Errorf("ignored tokens should not count as drift, got: %s", res.Reason)
+	}
+	res := check(strings.Replace(fmt.Sprintf(page, "abc"), "Welcome", "Hacked by someone", 1))
+	if res.IsHealthy {
+		t.Fatal("a changed heading should be reported")
+	}
+	for _, want := range []string{"--- baseline", "+++ current", "@@ -1,4 +1,4 @@", "-<h1>Welcome</h1>", "+<h1>Hacked by someone</h1>", " <p>Prices</p>"} {
+		if !strings.Contains(res.Reason, want) {
+			t.Errorf("diff is missing %q:\n%s", want, res.Reason)
+		}
+	}
+
+	jsonCond := model.Leaf(model.ConditionContentDrift, &model.ContentDriftCondition{
+		JSONPaths:      []string{"$.features", "$.limits['max_upload']"},
+		UpdateBaseline: true,
+	})
+	if err := jsonCond.Validate("test"); err != nil {
+		t.Fatalf("Validation should pass, got: %v", err)
+	}
+	evalJSON := func(body string) model.EvaluationResult {
+		res := jsonCond.EvaluateContext(&model.ResponseContext{Body: []byte(body), Baselines: store})
+		store.Commit()
+		return res
+	}
+	evalJSON(`{"features": ["a", "b"], "limits": {"max_upload": 10}, "now": 1}`)
+	if res := evalJSON(`{"features": ["a", "b"], "limits": {"max_upload": 10}, "now": 2}`); !res.IsHealthy {
+		t.Errorf("fields outside the paths should not count, got: %s", res.Reason)
+	}
+	if res := evalJSON(`{"features": ["a"], "limits": {"max_upload": 10}}`); res.IsHealthy || !strings.Contains(res.Reason, `-  "b"`) {
+		t.Errorf("a removed feature should be reported, got: %s", res.Reason)
+	}
+	if res := evalJSON(`{"features": ["a"], "limits": {"max_upload": 10}}`); !res.IsHealthy {
+		t.Errorf("update_baseline should accept the new body after reporting it, got: %s", res.Reason)
+	}
+
+	for _, invalid := range []*model.ContentDriftCondition{
+		{Ignore: []string{"("}},
+		{JSONPaths: []string{"features"}},
+		{SHA256: "abc"},
+	} {
+		if err := model.Leaf(model.ConditionContentDrift, invalid).Validate("test"); err == nil {
+			t.Errorf("Validation should fail for %+v", invalid)
+		}
+	}
+}
+
+func TestServiceInlineCondition(t *testing.T) {
+	src := `
+- name: inline
+  url: http://example.com
+  condition:
+    header: [{key: Content-Type, operator: contains, value: json}]
+- name: shorthand
+  url: http://example.com
+  expected_status_code: 204
+- name: default
+  url: http://example.com
+  timeout: 2
+- name: named
+  url: http://example.com
+  condition_id: api
+- name: both
+  url: http://example.com
+  condition_id: api
+  expected_status_code: 200
+`
+	var services []model.Service
+	if err := yaml.Unmarshal([]byte(src), &services); err != nil {
+		t.Fatalf("failed to parse YAML: %v", err)
+	}
+	conds := make([]*model.Condition, len(services))
+	for i := range services[:4] {
+		cond, err := services[i].InlineCondition()
+		if err != nil {
+			t.Fatalf("%s: %v", services[i].Name, err)
+		}
+		if cond != nil {
+			if err := cond.Validate("test"); err != nil {
+				t.Fatalf("%s: Validation should pass, got: %v", services[i].Name, err)
+			}
+		}
+		conds[i] = cond
+	}
+	if conds[3] != nil {
+		t.Error("a service with condition_id has no inline condition")
+	}
+	if _, err := services[4].InlineCondition(); err == nil {
+		t.Error("condition_id together with expected_status_code should be rejected")
+	}
+
+	resp := newMockResponse(204, "")
+	resp.Header = http.Header{"Content-Type": []string{"application/json; charset=utf-8"}}
+	if res := conds[0].Evaluate(resp, nil, time.Second); !res.IsHealthy {
+		t.Errorf("inline condition should pass, got: %s", res.Reason)
+	}
+	if res := conds[1].Evaluate(resp, nil, time.Second); !res.IsHealthy {
+		t.Errorf("expected_status_code 204 should pass, got: %s", res.Reason)
+	}
+	if res := conds[2].Evaluate(resp, nil, time.Second); !res.IsHealthy {
+		t.Errorf("default condition should accept a fast 2xx, got: %s", res.Reason)
+	}
+	if res := conds[2].Evaluate(resp, nil, 3*time.Second); res.IsHealthy {
+		t.Error("default condition should reject responses slower than the timeout")
+	}
+	if res := conds[2].Evaluate(newMockResponse(503, ""), nil, time.Second); res.IsHealthy {
+		t.Error("default condition should reject a 503")
+	}
+}
+
+func TestRedirectCondition(t *testing.T) {
+	src := `redirect:
+  final_url: "https://www.example.com/"
+  max_hops: 2
+  https_upgrade: true
+  hops:
+    - status: 301
+`
+	var cond model.Condition
+	if err := yaml.Unmarshal([]byte(src), &cond); err != nil {
+		t.Fatalf("failed to parse YAML: %v", err)
+	}
+	if err := cond.Validate("test"); err != nil {
+		t.Fatalf("Validation should pass, got: %v", err)
+	}
+	check := func(hops ...model.RedirectHop) model.EvaluationResult {
+		return cond.EvaluateContext(&model.ResponseContext{Redirects: hops})
+	}
+
+	good := []model.RedirectHop{
+		{From: "http://example.com/", To: "https://example.com/", StatusCode: 301},
+		{From: "https://example.com/", To: "https://www.example.com/", StatusCode: 302},
+	}
+	if res := check(good...); !res.IsHealthy {
+		t.Errorf("Should be healthy, but got: %s", res.Reason)
+	}
+
+	cases := map[string][]model.RedirectHop{
+		"temporary first hop": {{From: "http://example.com/", To: "https://www.example.com/", StatusCode: 302}},
+		"too many hops": append(append([]model.RedirectHop(nil), good...),
+			model.RedirectHop{From: "https://www.example.com/", To: "https://www.example.com/", StatusCode: 302}),
+		"no https":     {{From: "http://example.com/", To: "http://www.example.com/", StatusCode: 301}},
+		"downgrade":    {{From: "https://example.com/", To: "http://www.example.com/", StatusCode: 301}, {From: "http://www.example.com/", To: "https://www.example.com/", StatusCode: 301}},
+		"wrong target": {{From: "http://example.com/", To: "https://example.org/", StatusCode: 301}},
+		"no redirects": nil,
+	}
+	for name, hops := range cases {
+		if res := check(hops...); res.IsHealthy {
+			t.Errorf("%s: should fail", name)
+		}
+	}
+
+	invalid := model.Leaf(model.ConditionRedirect, &model.RedirectCondition{Hops: []model.RedirectHopAssertion{{Status: 200}}})
+	if err := invalid.Validate("test"); err == nil {
+		t.Error("Validation should fail for a non-redirect hop status")
+	}
+}
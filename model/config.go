@@ -9,11 +9,19 @@ type Service struct {
 	ConditionName string   `yaml:"condition_id"`
 	Threshold     int      `yaml:"threshold"` 
 	UserAgent     string   `yaml:"user_agent"`
+	// Condition is an inline condition tree, used instead of condition_id.
+	Condition *Condition `yaml:"condition,omitempty"`
+	// ExpectedStatusCode is shorthand for a status_code condition.
+	ExpectedStatusCode int `yaml:"expected_status_code,omitempty"`
+	Timeout            int `yaml:"timeout,omitempty"` // request timeout in seconds, 15 by default
 }
 
 type Target struct {
 	NotifierID string   `yaml:"notifier_id"`
 	Recipients []string `yaml:"recipients"`
+	// Severities limits the target to failures of these severities, e.g.
+	// [warning] for slowness reports. Empty receives every failure.
+	Severities []Severity `yaml:"severities,omitempty"`
 }
 
 type Notifiers struct {
@@ -21,6 +29,18 @@ type Notifiers struct {
 	SMTPs    []SMTP    `yaml:"smtp"`
 	Webhook  []Webhook `yaml:"webhook"`
 	MeliPayamakPanels []MeliPayamakPanel `yaml:"meli_payamak_panel"`
+	Telegrams         []Telegram         `yaml:"telegram"`
+	Slacks            []Slack            `yaml:"slack"`
+	Discords          []Discord          `yaml:"discord"`
+	MSTeams           []MSTeams          `yaml:"msteams"`
+	PagerDuties       []PagerDuty        `yaml:"pagerduty"`
+	Opsgenies         []Opsgenie         `yaml:"opsgenie"`
+	Ntfys             []Ntfy             `yaml:"ntfy"`
+	Gotifys           []Gotify           `yaml:"gotify"`
+	Bales             []Bale             `yaml:"bale"`
+	Kavenegars        []Kavenegar        `yaml:"kavenegar"`
+	Matrices          []Matrix           `yaml:"matrix"`
+	Syslogs           []Syslog           `yaml:"syslog"`
 }
 
 type SMTP struct {
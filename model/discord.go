@@ -0,0 +1,36 @@
+package model
+
+// Discord posts alerts as embeds to webhooks. Recipients are webhook URLs.
+type Discord struct {
+	ID        string `yaml:"id"`
+	Username  string `yaml:"username,omitempty"`
+	AvatarURL string `yaml:"avatar_url,omitempty"`
+	// Title is a template for the embed title, "{{.Icon}} {{.ServiceName}}
+	// is {{.State}}" by default.
+	Title string `yaml:"title,omitempty"`
+}
+
+type DiscordWebhookMessage struct {
+	Username  string         `json:"username,omitempty"`
+	AvatarURL string         `json:"avatar_url,omitempty"`
+	Embeds    []DiscordEmbed `json:"embeds"`
+}
+
+type DiscordEmbed struct {
+	Title       string              `json:"title"`
+	Description string              `json:"description,omitempty"`
+	Color       int                 `json:"color"`
+	Fields      []DiscordEmbedField `json:"fields,omitempty"`
+	Footer      *DiscordEmbedFooter `json:"footer,omitempty"`
+	Timestamp   string              `json:"timestamp,omitempty"`
+}
+
+type DiscordEmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+type DiscordEmbedFooter struct {
+	Text string `json:"text"`
+}
@@ -0,0 +1,302 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	conditionevaluator "healthy-api/evaluator"
+)
+
+// ContentDriftCondition fails when the normalized body differs from a
+// baseline. The baseline is the body of the first check unless a
+// baseline_file or a pinned sha256 is given. Tag the node with
+// `severity: warning` to only degrade the service on drift.
+//
+//	content_drift:
+//	  ignore: ['csrf_token" value="[^"]*"', '\d{4}-\d{2}-\d{2}T[0-9:.]+Z']
+//	  json_paths: ["$.features", "$.limits.max_upload"]
+type ContentDriftCondition struct {
+	// Ignore lists regexes whose matches are removed before comparing,
+	// e.g. timestamps and CSRF tokens.
+	Ignore []string `yaml:"ignore,omitempty"`
+	// JSONPaths compares only these parts of a JSON body. Supported syntax:
+	// $, .name, ['name'], [index] and [*].
+	JSONPaths    []string `yaml:"json_paths,omitempty"`
+	BaselineFile string   `yaml:"baseline_file,omitempty"`
+	// SHA256 pins the hex digest of the normalized body. No diff can be
+	// shown since there is no baseline text.
+	SHA256 string `yaml:"sha256,omitempty"`
+	// UpdateBaseline reports a change once and then accepts the new body as
+	// the baseline, for endpoints that are expected to change occasionally.
+	UpdateBaseline bool `yaml:"update_baseline,omitempty"`
+	ContextLines   *int `yaml:"context_lines,omitempty"` // defaults to 3
+
+	compiled bool
+	ignore   []*regexp.Regexp
+	paths    [][]jsonPathStep
+	baseline *string
+}
+
+const (
+	defaultDiffContext = 3
+	maxDiffSnippet     = 40
+)
+
+func (d *ContentDriftCondition) compile() error {
+	if d.BaselineFile != "" && d.SHA256 != "" {
+		return fmt.Errorf("baseline_file and sha256 cannot be used together")
+	}
+	if d.SHA256 != "" {
+		if b, err := hex.DecodeString(d.SHA256); err != nil || len(b) != sha256.Size {
+			return fmt.Errorf("sha256 must be 64 hex characters")
+		}
+	}
+	if d.ContextLines != nil && *d.ContextLines < 0 {
+		return fmt.Errorf("context_lines cannot be negative")
+	}
+	d.ignore = nil
+	for _, pattern := range d.Ignore {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid ignore pattern '%s': %v", pattern, err)
+		}
+		d.ignore = append(d.ignore, re)
+	}
+	d.paths = nil
+	for _, p := range d.JSONPaths {
+		steps, err := parseJSONPath(p)
+		if err != nil {
+			return err
+		}
+		d.paths = append(d.paths, steps)
+	}
+	if d.BaselineFile != "" {
+		data, err := os.ReadFile(d.BaselineFile)
+		if err != nil {
+			return fmt.Errorf("failed to read baseline file (%s): %w", d.BaselineFile, err)
+		}
+		normalized, err := d.normalize(data)
+		if err != nil {
+			return fmt.Errorf("baseline file (%s): %w", d.BaselineFile, err)
+		}
+		d.baseline = &normalized
+	}
+	d.compiled = true
+	return nil
+}
+
+func (d *ContentDriftCondition) Validate() error {
+	return d.compile()
+}
+
+// normalize removes ignored parts of the body, or extracts the configured
+// JSON paths, and unifies line endings and trailing whitespace.
+func (d *ContentDriftCondition) normalize(body []byte) (string, error) {
+	text := string(body)
+	if len(d.paths) > 0 {
+		var doc any
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return "", fmt.Errorf("body is not valid JSON: %v", err)
+		}
+		var sb strings.Builder
+		for i, steps := range d.paths {
+			values := selectJSONPath(doc, steps)
+			var out any = values
+			if len(values) == 1 && !hasWildcard(steps) {
+				out = values[0]
+			}
+			data, err := json.MarshalIndent(out, "", "  ")
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(&sb, "# %s\n%s\n", d.JSONPaths[i], data)
+		}
+		text = sb.String()
+	}
+	for _, re := range d.ignore {
+		text = re.ReplaceAllString(text, "")
+	}
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, " \t\r")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n"), nil
+}
+
+func (d *ContentDriftCondition) Evaluate(ctx *ResponseContext) conditionevaluator.Result {
+	res := d.evaluate(ctx)
+	if d.SHA256 != "" {
+		res.Expected = "sha256 " + d.SHA256
+	} else {
+		res.Expected, res.Actual = "body unchanged from baseline", fmt.Sprintf("%d bytes", len(ctx.Body))
+	}
+	return res
+}
+
+func (d *ContentDriftCondition) evaluate(ctx *ResponseContext) conditionevaluator.Result {
+	// Baselines are keyed by the condition itself, so keep d even when
+	// compiling a copy because Validate was not called.
+	cfg := d
+	if !d.compiled {
+		c := *d
+		if err := c.compile(); err != nil {
+			return conditionevaluator.Result{IsHealthy: false, Reason: fmt.Sprintf("Invalid content_drift condition: %v", err)}
+		}
+		cfg = &c
+	}
+	current, err := cfg.normalize(ctx.Body)
+	if err != nil {
+		return conditionevaluator.Result{IsHealthy: false, Reason: fmt.Sprintf("Content drift check failed: %v", err)}
+	}
+	sum := sha256.Sum256([]byte(current))
+	digest := hex.EncodeToString(sum[:])
+
+	if d.SHA256 != "" {
+		if strings.EqualFold(digest, d.SHA256) {
+			return conditionevaluator.Result{IsHealthy: true}
+		}
+		return conditionevaluator.Result{
+			IsHealthy: false,
+			Reason:    fmt.Sprintf("Body changed: sha256 is %s, expected %s", digest[:12], strings.ToLower(d.SHA256[:12])),
+		}
+	}
+
+	var baseline string
+	switch {
+	case cfg.baseline != nil:
+		baseline = *cfg.baseline
+	case ctx.Baselines != nil:
+		var seen bool
+		if baseline, seen = ctx.Baselines.Get(d, current); !seen {
+			return conditionevaluator.Result{IsHealthy: true}
+		}
+	default:
+		// Nowhere to keep a baseline: nothing to compare with.
+		return conditionevaluator.Result{IsHealthy: true}
+	}
+	if baseline == current {
+		return conditionevaluator.Result{IsHealthy: true}
+	}
+	if d.UpdateBaseline && ctx.Baselines != nil {
+		ctx.Baselines.Replace(d, current)
+	}
+
+	contextLines := defaultDiffContext
+	if d.ContextLines != nil {
+		contextLines = *d.ContextLines
+	}
+	old := sha256.Sum256([]byte(baseline))
+	diff := unifiedDiff("baseline", "current", strings.Split(baseline, "\n"), strings.Split(current, "\n"), contextLines, maxDiffSnippet)
+	return conditionevaluator.Result{
+		IsHealthy: false,
+		Reason:    fmt.Sprintf("Body changed from baseline (sha256 %s -> %s):\n%s", hex.EncodeToString(old[:])[:12], digest[:12], diff),
+	}
+}
+
+// jsonPathStep is one segment of a JSON path: a key, an index, or a
+// wildcard over all items.
+type jsonPathStep struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+func parseJSONPath(path string) ([]jsonPathStep, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("json path '%s' must start with $", path)
+	}
+	var steps []jsonPathStep
+	rest := path[1:]
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, ".*") || strings.HasPrefix(rest, "[*]"):
+			steps = append(steps, jsonPathStep{wildcard: true})
+			if rest[0] == '.' {
+				rest = rest[2:]
+			} else {
+				rest = rest[3:]
+			}
+		case rest[0] == '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			key := rest[1 : end+1]
+			if key == "" {
+				return nil, fmt.Errorf("empty key in json path '%s'", path)
+			}
+			steps = append(steps, jsonPathStep{key: key})
+			rest = rest[end+1:]
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated '[' in json path '%s'", path)
+			}
+			inner := strings.TrimSpace(rest[1:end])
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				steps = append(steps, jsonPathStep{key: inner[1 : len(inner)-1]})
+			} else {
+				n, err := strconv.Atoi(inner)
+				if err != nil || n < 0 {
+					return nil, fmt.Errorf("invalid index '%s' in json path '%s'", inner, path)
+				}
+				steps = append(steps, jsonPathStep{index: n, isIndex: true})
+			}
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("unexpected '%c' in json path '%s'", rest[0], path)
+		}
+	}
+	return steps, nil
+}
+
+func hasWildcard(steps []jsonPathStep) bool {
+	for _, s := range steps {
+		if s.wildcard {
+			return true
+		}
+	}
+	return false
+}
+
+// selectJSONPath returns the values the path points to; missing parts
+// select nothing.
+func selectJSONPath(doc any, steps []jsonPathStep) []any {
+	values := []any{doc}
+	for _, step := range steps {
+		var next []any
+		for _, v := range values {
+			switch x := v.(type) {
+			case map[string]any:
+				if step.wildcard {
+					keys := make([]string, 0, len(x))
+					for k := range x {
+						keys = append(keys, k)
+					}
+					sort.Strings(keys)
+					for _, k := range keys {
+						next = append(next, x[k])
+					}
+				} else if val, ok := x[step.key]; ok && !step.isIndex {
+					next = append(next, val)
+				}
+			case []any:
+				if step.wildcard {
+					next = append(next, x...)
+				} else if step.isIndex && step.index < len(x) {
+					next = append(next, x[step.index])
+				}
+			}
+		}
+		values = next
+	}
+	return values
+}
@@ -0,0 +1,160 @@
+package model
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"healthy-api/expr"
+
+	"gopkg.in/yaml.v3"
+
+	conditionevaluator "healthy-api/evaluator"
+)
+
+// ExpressionCondition is written as a plain string in YAML:
+//
+//	expr: 'status in [200, 204] && json.queue.depth < 1000 && duration < 500ms'
+type ExpressionCondition struct {
+	Source  string
+	program *expr.Program
+}
+
+var responseEnv = expr.Env{
+	"status":   expr.Number,
+	"headers":  expr.MapOf(expr.String),
+	"body":     expr.String,
+	"json":     expr.Any,
+	"duration": expr.Duration,
+	"size":     expr.Number,
+	"timing": expr.ObjectOf(map[string]*expr.Type{
+		"dns":      expr.Duration,
+		"connect":  expr.Duration,
+		"tls":      expr.Duration,
+		"ttfb":     expr.Duration,
+		"download": expr.Duration,
+	}),
+	"tls": expr.ObjectOf(map[string]*expr.Type{
+		"enabled":     expr.Bool,
+		"version":     expr.String,
+		"cipher":      expr.String,
+		"server_name": expr.String,
+		"issuer":      expr.String,
+		"expires_in":  expr.Duration,
+	}),
+}
+
+func (e *ExpressionCondition) UnmarshalYAML(value *yaml.Node) error {
+	return value.Decode(&e.Source)
+}
+
+func (e ExpressionCondition) MarshalYAML() (interface{}, error) {
+	return e.Source, nil
+}
+
+func (e *ExpressionCondition) Compile() (*expr.Program, error) {
+	if e.program != nil {
+		return e.program, nil
+	}
+	return expr.Compile(e.Source, responseEnv)
+}
+
+// Validate parses and type-checks the expression and keeps the compiled
+// program for later evaluations.
+func (e *ExpressionCondition) Validate() error {
+	if strings.TrimSpace(e.Source) == "" {
+		return fmt.Errorf("expression is empty")
+	}
+	program, err := expr.Compile(e.Source, responseEnv)
+	if err != nil {
+		return err
+	}
+	e.program = program
+	return nil
+}
+
+func (e *ExpressionCondition) Evaluate(ctx *ResponseContext) conditionevaluator.Result {
+	res := e.evaluate(ctx)
+	res.Expected = e.Source
+	return res
+}
+
+func (e *ExpressionCondition) evaluate(ctx *ResponseContext) conditionevaluator.Result {
+	resp, body := ctx.Response, ctx.Body
+	program, err := e.Compile()
+	if err != nil {
+		return conditionevaluator.Result{IsHealthy: false, Reason: fmt.Sprintf("Invalid expression '%s': %v", e.Source, err)}
+	}
+	vars := map[string]any{
+		"status":   0,
+		"headers":  headerLookup(http.Header{}),
+		"body":     string(body),
+		"json":     nil,
+		"duration": ctx.Duration,
+		"size":     len(body),
+		"tls":      tlsVars(nil),
+		"timing": map[string]any{
+			"dns":      ctx.Timing.DNS,
+			"connect":  ctx.Timing.Connect,
+			"tls":      ctx.Timing.TLS,
+			"ttfb":     ctx.Timing.TTFB,
+			"download": ctx.Timing.Download,
+		},
+	}
+	if resp != nil {
+		vars["status"] = resp.StatusCode
+		vars["headers"] = headerLookup(resp.Header)
+		vars["tls"] = tlsVars(resp.TLS)
+	}
+	if program.Uses("json") {
+		var parsed any
+		if err := json.Unmarshal(body, &parsed); err != nil {
+			return conditionevaluator.Result{IsHealthy: false, Reason: fmt.Sprintf("Expression '%s' needs a JSON body: %v", e.Source, err)}
+		}
+		vars["json"] = parsed
+	}
+	ok, err := program.Eval(vars)
+	if err != nil {
+		return conditionevaluator.Result{IsHealthy: false, Reason: fmt.Sprintf("Expression '%s' failed: %v", e.Source, err)}
+	}
+	if !ok {
+		return conditionevaluator.Result{IsHealthy: false, Reason: fmt.Sprintf("Expression '%s' is false", e.Source)}
+	}
+	return conditionevaluator.Result{IsHealthy: true}
+}
+
+type headerLookup http.Header
+
+func (h headerLookup) Lookup(key string) (any, bool) {
+	values := http.Header(h).Values(key)
+	if len(values) == 0 {
+		return nil, false
+	}
+	return strings.Join(values, ", "), true
+}
+
+func tlsVars(state *tls.ConnectionState) map[string]any {
+	vars := map[string]any{
+		"enabled":     state != nil,
+		"version":     "",
+		"cipher":      "",
+		"server_name": "",
+		"issuer":      "",
+		"expires_in":  time.Duration(0),
+	}
+	if state == nil {
+		return vars
+	}
+	vars["version"] = tls.VersionName(state.Version)
+	vars["cipher"] = tls.CipherSuiteName(state.CipherSuite)
+	vars["server_name"] = state.ServerName
+	if len(state.PeerCertificates) > 0 {
+		cert := state.PeerCertificates[0]
+		vars["issuer"] = cert.Issuer.CommonName
+		vars["expires_in"] = time.Until(cert.NotAfter)
+	}
+	return vars
+}
@@ -0,0 +1,95 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"healthy-api/jsonschema"
+
+	conditionevaluator "healthy-api/evaluator"
+)
+
+// JSONSchemaCondition validates the response body against a JSON Schema given
+// either inline (as YAML) or in a file.
+type JSONSchemaCondition struct {
+	File     string                 `yaml:"file,omitempty"`
+	Schema   map[string]interface{} `yaml:"schema,omitempty"`
+	compiled *jsonschema.Schema
+}
+
+const maxReportedSchemaErrors = 3
+
+func (j *JSONSchemaCondition) Compile() (*jsonschema.Schema, error) {
+	if j.compiled != nil {
+		return j.compiled, nil
+	}
+	if (j.File == "") == (j.Schema == nil) {
+		return nil, fmt.Errorf("exactly one of file or schema is required")
+	}
+	var data []byte
+	if j.File != "" {
+		var err error
+		if data, err = os.ReadFile(j.File); err != nil {
+			return nil, fmt.Errorf("failed to read schema file (%s): %w", j.File, err)
+		}
+	} else {
+		var err error
+		if data, err = json.Marshal(j.Schema); err != nil {
+			return nil, fmt.Errorf("inline schema cannot be converted to JSON: %w", err)
+		}
+	}
+	return jsonschema.Compile(data)
+}
+
+func (j *JSONSchemaCondition) Validate() error {
+	schema, err := j.Compile()
+	if err != nil {
+		return err
+	}
+	j.compiled = schema
+	return nil
+}
+
+func (j *JSONSchemaCondition) Evaluate(ctx *ResponseContext) conditionevaluator.Result {
+	res := j.evaluate(ctx.Body)
+	res.Expected = "inline schema"
+	if j.File != "" {
+		res.Expected = "schema " + j.File
+	}
+	return res
+}
+
+func (j *JSONSchemaCondition) evaluate(body []byte) conditionevaluator.Result {
+	schema, err := j.Compile()
+	if err != nil {
+		return conditionevaluator.Result{IsHealthy: false, Reason: fmt.Sprintf("Invalid JSON schema: %v", err)}
+	}
+	violations, err := schema.ValidateJSON(body)
+	if err != nil {
+		return conditionevaluator.Result{IsHealthy: false, Reason: fmt.Sprintf("JSON schema validation failed: %v", err)}
+	}
+	if len(violations) == 0 {
+		return conditionevaluator.Result{IsHealthy: true}
+	}
+	var reasons []string
+	for i, v := range violations {
+		if i == maxReportedSchemaErrors {
+			reasons = append(reasons, fmt.Sprintf("and %d more", len(violations)-i))
+			break
+		}
+		reasons = append(reasons, fmt.Sprintf("%s (keyword '%s': %s)", pointerOrRoot(v.InstancePath), v.Keyword, v.Message))
+	}
+	return conditionevaluator.Result{
+		IsHealthy: false,
+		Reason:    "Body does not match JSON schema at " + strings.Join(reasons, "; "),
+	}
+}
+
+func pointerOrRoot(p string) string {
+	if p == "" {
+		return "/"
+	}
+	return p
+}
@@ -0,0 +1,38 @@
+package model
+
+// Kavenegar sends SMS through the Kavenegar REST API. Recipients are phone
+// numbers. Alerts use sms/send with Template as the text, or verify/lookup
+// when LookupTemplate names a template defined in the Kavenegar panel.
+type Kavenegar struct {
+	ID     string `yaml:"id"`
+	APIKey string `yaml:"api_key"`
+	// Sender is the line number of sms/send; the account's default line
+	// when empty. Lookup messages always use the service line.
+	Sender string `yaml:"sender,omitempty"`
+	// APIBaseURL is https://api.kavenegar.com by default.
+	APIBaseURL     string `yaml:"api_base_url,omitempty"`
+	Template       string `yaml:"template,omitempty"`
+	LookupTemplate string `yaml:"lookup_template,omitempty"`
+	// Tokens maps the lookup parameters token, token2, token3, token10 and
+	// token20 to notification fields: service_name, url, state, severity,
+	// reason, status_code or response_time. {token: service_name} by
+	// default.
+	Tokens map[string]string `yaml:"tokens,omitempty"`
+}
+
+// KavenegarResponse is the envelope of every Kavenegar API response.
+type KavenegarResponse struct {
+	Return struct {
+		Status  int    `json:"status"`
+		Message string `json:"message"`
+	} `json:"return"`
+	Entries []KavenegarEntry `json:"entries"`
+}
+
+type KavenegarEntry struct {
+	MessageID  int64  `json:"messageid"`
+	Status     int    `json:"status"`
+	StatusText string `json:"statustext"`
+	Receptor   string `json:"receptor"`
+	Cost       int    `json:"cost"`
+}
@@ -0,0 +1,208 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"healthy-api/markup"
+
+	conditionevaluator "healthy-api/evaluator"
+)
+
+// MarkupAssertion holds the checks shared by css_selector and xpath
+// conditions. Without min_count/max_count at least one match is required.
+type MarkupAssertion struct {
+	Text      string `yaml:"text,omitempty"`      // trimmed value must equal this
+	Pattern   string `yaml:"pattern,omitempty"`   // trimmed value must match this regex
+	Attribute string `yaml:"attribute,omitempty"` // compare this attribute instead of the text
+	MinCount  *int   `yaml:"min_count,omitempty"`
+	MaxCount  *int   `yaml:"max_count,omitempty"`
+	re        *regexp.Regexp
+}
+
+type CSSSelectorCondition struct {
+	Selector        string `yaml:"selector"`
+	XML             bool   `yaml:"xml,omitempty"` // parse the body as XML instead of HTML
+	MarkupAssertion `yaml:",inline"`
+	compiled        *markup.Selector
+}
+
+type XPathCondition struct {
+	Path            string `yaml:"path"`
+	HTML            bool   `yaml:"html,omitempty"` // parse the body as HTML instead of XML
+	MarkupAssertion `yaml:",inline"`
+	compiled        *markup.XPath
+}
+
+func (m *MarkupAssertion) validate() error {
+	if m.Text != "" && m.Pattern != "" {
+		return fmt.Errorf("text and pattern cannot be used together")
+	}
+	if m.MinCount != nil && *m.MinCount < 0 || m.MaxCount != nil && *m.MaxCount < 0 {
+		return fmt.Errorf("min_count and max_count cannot be negative")
+	}
+	if m.MinCount != nil && m.MaxCount != nil && *m.MinCount > *m.MaxCount {
+		return fmt.Errorf("min_count %d is greater than max_count %d", *m.MinCount, *m.MaxCount)
+	}
+	if m.Pattern != "" {
+		re, err := regexp.Compile(m.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern '%s': %v", m.Pattern, err)
+		}
+		m.re = re
+	}
+	return nil
+}
+
+func (m *MarkupAssertion) check(kind, query string, values []string) conditionevaluator.Result {
+	count := len(values)
+	min := 1
+	if m.MinCount != nil {
+		min = *m.MinCount
+	} else if m.MaxCount != nil {
+		min = 0
+	}
+	if count < min {
+		return conditionevaluator.Result{IsHealthy: false, Reason: fmt.Sprintf("%s '%s' matched %d element(s), expected at least %d", kind, query, count, min)}
+	}
+	if m.MaxCount != nil && count > *m.MaxCount {
+		return conditionevaluator.Result{IsHealthy: false, Reason: fmt.Sprintf("%s '%s' matched %d element(s), expected at most %d", kind, query, count, *m.MaxCount)}
+	}
+	if m.Text == "" && m.Pattern == "" {
+		return conditionevaluator.Result{IsHealthy: true}
+	}
+	re := m.re
+	if m.Pattern != "" && re == nil {
+		var err error
+		if re, err = regexp.Compile(m.Pattern); err != nil {
+			return conditionevaluator.Result{IsHealthy: false, Reason: fmt.Sprintf("Invalid pattern '%s': %v", m.Pattern, err)}
+		}
+	}
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if (m.Text != "" && v == m.Text) || (re != nil && re.MatchString(v)) {
+			return conditionevaluator.Result{IsHealthy: true}
+		}
+	}
+	expected := fmt.Sprintf("'%s'", m.Text)
+	if m.Pattern != "" {
+		expected = fmt.Sprintf("matching '%s'", m.Pattern)
+	}
+	return conditionevaluator.Result{
+		IsHealthy: false,
+		Reason:    fmt.Sprintf("%s '%s' has no value %s (got %s)", kind, query, expected, quoteValues(values)),
+	}
+}
+
+func quoteValues(values []string) string {
+	const max = 3
+	var quoted []string
+	for i, v := range values {
+		if i == max {
+			quoted = append(quoted, fmt.Sprintf("and %d more", len(values)-max))
+			break
+		}
+		quoted = append(quoted, fmt.Sprintf("'%s'", strings.TrimSpace(v)))
+	}
+	if len(quoted) == 0 {
+		return "nothing"
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func (c *CSSSelectorCondition) Validate() error {
+	sel, err := markup.CompileSelector(c.Selector)
+	if err != nil {
+		return err
+	}
+	if err := c.MarkupAssertion.validate(); err != nil {
+		return err
+	}
+	c.compiled = sel
+	return nil
+}
+
+func (c *CSSSelectorCondition) Evaluate(ctx *ResponseContext) conditionevaluator.Result {
+	res := c.evaluate(ctx.Body)
+	res.Expected = c.Selector
+	return res
+}
+
+func (c *CSSSelectorCondition) evaluate(body []byte) conditionevaluator.Result {
+	sel := c.compiled
+	if sel == nil {
+		var err error
+		if sel, err = markup.CompileSelector(c.Selector); err != nil {
+			return conditionevaluator.Result{IsHealthy: false, Reason: err.Error()}
+		}
+	}
+	doc, err := parseMarkup(body, !c.XML)
+	if err != nil {
+		return conditionevaluator.Result{IsHealthy: false, Reason: err.Error()}
+	}
+	var values []string
+	for _, n := range sel.Select(doc) {
+		if c.Attribute != "" {
+			v, ok := n.Attr(c.Attribute)
+			if !ok {
+				continue
+			}
+			values = append(values, v)
+		} else {
+			values = append(values, n.Text())
+		}
+	}
+	return c.MarkupAssertion.check("CSS selector", c.Selector, values)
+}
+
+func (x *XPathCondition) Validate() error {
+	if x.Attribute != "" {
+		return fmt.Errorf("attribute is not supported for xpath, select it with /@name instead")
+	}
+	path, err := markup.CompileXPath(x.Path)
+	if err != nil {
+		return err
+	}
+	if err := x.MarkupAssertion.validate(); err != nil {
+		return err
+	}
+	x.compiled = path
+	return nil
+}
+
+func (x *XPathCondition) Evaluate(ctx *ResponseContext) conditionevaluator.Result {
+	res := x.evaluate(ctx.Body)
+	res.Expected = x.Path
+	return res
+}
+
+func (x *XPathCondition) evaluate(body []byte) conditionevaluator.Result {
+	path := x.compiled
+	if path == nil {
+		var err error
+		if path, err = markup.CompileXPath(x.Path); err != nil {
+			return conditionevaluator.Result{IsHealthy: false, Reason: err.Error()}
+		}
+	}
+	doc, err := parseMarkup(body, x.HTML)
+	if err != nil {
+		return conditionevaluator.Result{IsHealthy: false, Reason: err.Error()}
+	}
+	var values []string
+	for _, m := range path.Select(doc) {
+		values = append(values, m.Value())
+	}
+	return x.MarkupAssertion.check("XPath", x.Path, values)
+}
+
+func parseMarkup(body []byte, html bool) (*markup.Node, error) {
+	if html {
+		return markup.ParseHTML(body), nil
+	}
+	doc, err := markup.ParseXML(body)
+	if err != nil {
+		return nil, fmt.Errorf("Body is not valid XML: %v", err)
+	}
+	return doc, nil
+}
@@ -0,0 +1,34 @@
+package model
+
+// Matrix posts alerts to rooms through the client-server API. Recipients
+// are room IDs (e.g. "!abcdefg:matrix.my-company.ir"); the bot account
+// must already be joined to them.
+type Matrix struct {
+	ID            string `yaml:"id"`
+	HomeserverURL string `yaml:"homeserver_url"`
+	AccessToken   string `yaml:"access_token"`
+	// MsgType is m.text or m.notice; bots usually use m.notice, which
+	// clients show without a sound. m.text by default.
+	MsgType string `yaml:"msgtype,omitempty"`
+	// Template renders the HTML formatted_body. The plain body always uses
+	// the plain-text default.
+	Template string `yaml:"template,omitempty"`
+}
+
+type MatrixMessage struct {
+	MsgType       string `json:"msgtype"`
+	Body          string `json:"body"`
+	Format        string `json:"format,omitempty"`
+	FormattedBody string `json:"formatted_body,omitempty"`
+}
+
+type MatrixSendResponse struct {
+	EventID string `json:"event_id"`
+}
+
+// MatrixError is the error body of the client-server API.
+type MatrixError struct {
+	ErrCode      string `json:"errcode"`
+	Error        string `json:"error"`
+	RetryAfterMs int64  `json:"retry_after_ms,omitempty"`
+}
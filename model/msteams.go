@@ -0,0 +1,46 @@
+package model
+
+// MSTeams posts alerts as Adaptive Cards. Recipients are incoming webhook or
+// Workflows ("Post to a channel when a webhook request is received") URLs.
+type MSTeams struct {
+	ID string `yaml:"id"`
+	// Title is a template for the card title, "{{.Icon}} {{.ServiceName}}
+	// is {{.State}}" by default.
+	Title string `yaml:"title,omitempty"`
+}
+
+type TeamsMessage struct {
+	Type        string            `json:"type"`
+	Attachments []TeamsAttachment `json:"attachments"`
+}
+
+type TeamsAttachment struct {
+	ContentType string       `json:"contentType"`
+	Content     AdaptiveCard `json:"content"`
+}
+
+type AdaptiveCard struct {
+	Schema  string              `json:"$schema"`
+	Type    string              `json:"type"`
+	Version string              `json:"version"`
+	Body    []AdaptiveCardBlock `json:"body"`
+	MSTeams map[string]string   `json:"msteams,omitempty"`
+}
+
+// AdaptiveCardBlock is a TextBlock or a FactSet.
+type AdaptiveCardBlock struct {
+	Type     string             `json:"type"`
+	Text     string             `json:"text,omitempty"`
+	Weight   string             `json:"weight,omitempty"`
+	Size     string             `json:"size,omitempty"`
+	Color    string             `json:"color,omitempty"`
+	FontType string             `json:"fontType,omitempty"`
+	IsSubtle bool               `json:"isSubtle,omitempty"`
+	Wrap     bool               `json:"wrap,omitempty"`
+	Facts    []AdaptiveCardFact `json:"facts,omitempty"`
+}
+
+type AdaptiveCardFact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
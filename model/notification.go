@@ -2,8 +2,14 @@ package model
 
 type Notification struct {
 	ServiceName string
+	URL         string // the checked service URL
 	Recipients  []string
 	Reason      string 
 	StatusCode   int    
 	ResponseTime string 
+	Timing       Timing
+	State        ServiceState
+	Severity     Severity
+	// Trace explains which condition nodes failed; nil for network errors.
+	Trace *TraceNode
 }
@@ -0,0 +1,49 @@
+package model
+
+// Opsgenie creates an alert per service (alias = service name), adds a note
+// when the failure reason changes and closes the alert on recovery.
+// Recipients are extra responders in the same form as Responders.
+type Opsgenie struct {
+	ID     string `yaml:"id"`
+	APIKey string `yaml:"api_key"`
+	// APIBaseURL is https://api.opsgenie.com by default; EU accounts use
+	// https://api.eu.opsgenie.com.
+	APIBaseURL string `yaml:"api_base_url,omitempty"`
+	// Responders are "team:<name>", "user:<username>", "escalation:<name>"
+	// or "schedule:<name>".
+	Responders []string `yaml:"responders,omitempty"`
+	Tags       []string `yaml:"tags,omitempty"`
+	// Priorities maps severities to P1..P5; critical is P1 and warning P3
+	// by default.
+	Priorities map[Severity]string `yaml:"priorities,omitempty"`
+}
+
+type OpsgenieAlert struct {
+	Message     string              `json:"message"`
+	Alias       string              `json:"alias"`
+	Description string              `json:"description,omitempty"`
+	Responders  []OpsgenieResponder `json:"responders,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Details     map[string]string   `json:"details,omitempty"`
+	Entity      string              `json:"entity,omitempty"`
+	Source      string              `json:"source,omitempty"`
+	Priority    string              `json:"priority,omitempty"`
+}
+
+type OpsgenieResponder struct {
+	Type     string `json:"type"`
+	Name     string `json:"name,omitempty"`
+	Username string `json:"username,omitempty"`
+}
+
+// OpsgenieNote is the body of the close and add-note requests.
+type OpsgenieNote struct {
+	Source string `json:"source,omitempty"`
+	Note   string `json:"note,omitempty"`
+}
+
+type OpsgenieResponse struct {
+	Result    string `json:"result,omitempty"`
+	Message   string `json:"message,omitempty"`
+	RequestID string `json:"requestId,omitempty"`
+}
@@ -0,0 +1,42 @@
+package model
+
+// PagerDuty sends Events API v2 events: trigger on failures and resolve on
+// recovery. Recipients are integration (routing) keys.
+type PagerDuty struct {
+	ID string `yaml:"id"`
+	// EventsURL is https://events.pagerduty.com/v2/enqueue by default.
+	EventsURL string `yaml:"events_url,omitempty"`
+	// Source is the affected system shown in the incident, "healthy-api" by
+	// default.
+	Source string `yaml:"source,omitempty"`
+	// Component defaults to the service name.
+	Component string `yaml:"component,omitempty"`
+	Group     string `yaml:"group,omitempty"`
+	Class     string `yaml:"class,omitempty"`
+}
+
+type PagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *PagerDutyPayload `json:"payload,omitempty"`
+	Client      string            `json:"client,omitempty"`
+}
+
+type PagerDutyPayload struct {
+	Summary       string         `json:"summary"`
+	Source        string         `json:"source"`
+	Severity      string         `json:"severity"`
+	Timestamp     string         `json:"timestamp,omitempty"`
+	Component     string         `json:"component,omitempty"`
+	Group         string         `json:"group,omitempty"`
+	Class         string         `json:"class,omitempty"`
+	CustomDetails map[string]any `json:"custom_details,omitempty"`
+}
+
+type PagerDutyResponse struct {
+	Status   string   `json:"status"`
+	Message  string   `json:"message"`
+	DedupKey string   `json:"dedup_key,omitempty"`
+	Errors   []string `json:"errors,omitempty"`
+}
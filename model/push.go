@@ -0,0 +1,60 @@
+package model
+
+// PushAuth authenticates against a self-hosted push server or the reverse
+// proxy in front of it: a bearer access token, or basic auth.
+type PushAuth struct {
+	Username    string `yaml:"username,omitempty"`
+	Password    string `yaml:"password,omitempty"`
+	AccessToken string `yaml:"access_token,omitempty"`
+}
+
+// Ntfy publishes to topics of an ntfy server. Recipients are topics; Topic
+// is used for targets without recipients.
+type Ntfy struct {
+	ID        string `yaml:"id"`
+	ServerURL string `yaml:"server_url"`
+	Topic     string `yaml:"topic,omitempty"`
+	PushAuth  `yaml:",inline"`
+	// Priorities maps severities to ntfy priorities 1 (min) to 5 (urgent);
+	// critical is 5 and warning 3 by default. Recoveries use 3.
+	Priorities map[Severity]int `yaml:"priorities,omitempty"`
+	// Tags are added to the state's emoji tag (rotating_light, warning or
+	// white_check_mark).
+	Tags []string `yaml:"tags,omitempty"`
+	// ClickURL is opened when the notification is tapped; the URL of the
+	// failing service by default.
+	ClickURL string `yaml:"click_url,omitempty"`
+	Template string `yaml:"template,omitempty"`
+}
+
+type NtfyMessage struct {
+	Topic    string   `json:"topic"`
+	Title    string   `json:"title,omitempty"`
+	Message  string   `json:"message"`
+	Priority int      `json:"priority,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Click    string   `json:"click,omitempty"`
+}
+
+// Gotify sends messages to a Gotify server. Recipients are application
+// tokens; AppToken is used for targets without recipients.
+type Gotify struct {
+	ID        string `yaml:"id"`
+	ServerURL string `yaml:"server_url"`
+	AppToken  string `yaml:"app_token,omitempty"`
+	// PushAuth is only needed when a reverse proxy protects the server;
+	// Gotify itself authenticates with the app token.
+	PushAuth `yaml:",inline"`
+	// Priorities maps severities to Gotify priorities 0 to 10; critical is
+	// 8 and warning 5 by default. Recoveries use 4.
+	Priorities map[Severity]int `yaml:"priorities,omitempty"`
+	ClickURL   string           `yaml:"click_url,omitempty"`
+	Template   string           `yaml:"template,omitempty"`
+}
+
+type GotifyMessage struct {
+	Title    string         `json:"title,omitempty"`
+	Message  string         `json:"message"`
+	Priority int            `json:"priority"`
+	Extras   map[string]any `json:"extras,omitempty"`
+}
@@ -0,0 +1,137 @@
+package model
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	conditionevaluator "healthy-api/evaluator"
+)
+
+// RedirectCondition asserts the redirect chain of a check, e.g.
+//
+//	redirect:
+//	  final_url: "https://www.example.com/"
+//	  max_hops: 2
+//	  https_upgrade: true
+//	  hops:
+//	    - status: 301
+type RedirectCondition struct {
+	FinalURL string `yaml:"final_url,omitempty"`
+	MaxHops  *int   `yaml:"max_hops,omitempty"`
+	// HTTPSUpgrade requires the chain to end on https and never go from
+	// https back to http.
+	HTTPSUpgrade bool `yaml:"https_upgrade,omitempty"`
+	// Hops are matched in order against the first hops of the chain.
+	Hops []RedirectHopAssertion `yaml:"hops,omitempty"`
+}
+
+type RedirectHopAssertion struct {
+	Status   int    `yaml:"status,omitempty"`   // e.g. 301 for permanent redirects
+	Location string `yaml:"location,omitempty"` // URL the hop must point to
+}
+
+func (r *RedirectCondition) Validate() error {
+	if r.FinalURL == "" && r.MaxHops == nil && !r.HTTPSUpgrade && len(r.Hops) == 0 {
+		return fmt.Errorf("one of final_url, max_hops, https_upgrade or hops is required")
+	}
+	if r.FinalURL != "" {
+		if u, err := url.Parse(r.FinalURL); err != nil || !u.IsAbs() {
+			return fmt.Errorf("final_url '%s' must be an absolute URL", r.FinalURL)
+		}
+	}
+	if r.MaxHops != nil && *r.MaxHops < 0 {
+		return fmt.Errorf("max_hops cannot be negative")
+	}
+	for i, h := range r.Hops {
+		if h.Status != 0 && (h.Status < 300 || h.Status > 399) {
+			return fmt.Errorf("hops[%d]: status %d is not a redirect status", i, h.Status)
+		}
+		if h.Status == 0 && h.Location == "" {
+			return fmt.Errorf("hops[%d]: status or location is required", i)
+		}
+	}
+	return nil
+}
+
+func (r *RedirectCondition) Evaluate(ctx *ResponseContext) conditionevaluator.Result {
+	res := r.evaluate(ctx)
+	res.Expected, res.Actual = r.String(), describeChain(ctx.Redirects, ctx.FinalURL())
+	return res
+}
+
+func (r *RedirectCondition) evaluate(ctx *ResponseContext) conditionevaluator.Result {
+	hops := ctx.Redirects
+	final := ctx.FinalURL()
+	fail := func(format string, args ...any) conditionevaluator.Result {
+		return conditionevaluator.Result{IsHealthy: false, Reason: fmt.Sprintf(format, args...) + " (chain: " + describeChain(hops, final) + ")"}
+	}
+	if r.MaxHops != nil && len(hops) > *r.MaxHops {
+		return fail("Redirect chain has %d hops, at most %d allowed", len(hops), *r.MaxHops)
+	}
+	for i, want := range r.Hops {
+		if i >= len(hops) {
+			return fail("Expected redirect hop %d, but the chain has only %d", i+1, len(hops))
+		}
+		if want.Status != 0 && hops[i].StatusCode != want.Status {
+			return fail("Redirect hop %d used status %d, expected %d", i+1, hops[i].StatusCode, want.Status)
+		}
+		if want.Location != "" && hops[i].To != want.Location {
+			return fail("Redirect hop %d went to %s, expected %s", i+1, hops[i].To, want.Location)
+		}
+	}
+	if r.HTTPSUpgrade {
+		for i, h := range hops {
+			if isHTTPS(h.From) && !isHTTPS(h.To) {
+				return fail("Redirect hop %d downgrades from HTTPS to HTTP", i+1)
+			}
+		}
+		if !isHTTPS(final) {
+			return fail("Final URL %s is not HTTPS", final)
+		}
+	}
+	if r.FinalURL != "" && final != r.FinalURL {
+		return fail("Final URL is %s, expected %s", final, r.FinalURL)
+	}
+	return conditionevaluator.Result{IsHealthy: true}
+}
+
+func (r *RedirectCondition) String() string {
+	var parts []string
+	if r.FinalURL != "" {
+		parts = append(parts, "final_url="+r.FinalURL)
+	}
+	if r.MaxHops != nil {
+		parts = append(parts, fmt.Sprintf("max_hops=%d", *r.MaxHops))
+	}
+	if r.HTTPSUpgrade {
+		parts = append(parts, "https_upgrade")
+	}
+	for i, h := range r.Hops {
+		hop := fmt.Sprintf("hop%d", i+1)
+		if h.Status != 0 {
+			hop += fmt.Sprintf("=%d", h.Status)
+		}
+		if h.Location != "" {
+			hop += " to " + h.Location
+		}
+		parts = append(parts, hop)
+	}
+	return strings.Join(parts, " ")
+}
+
+func isHTTPS(rawURL string) bool {
+	return strings.HasPrefix(strings.ToLower(rawURL), "https://")
+}
+
+func describeChain(hops []RedirectHop, final string) string {
+	if len(hops) == 0 {
+		return "no redirects, " + final
+	}
+	var sb strings.Builder
+	sb.WriteString(hops[0].From)
+	for _, h := range hops {
+		fmt.Fprintf(&sb, " -%d-> %s", h.StatusCode, h.To)
+	}
+	return sb.String()
+}
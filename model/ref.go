@@ -0,0 +1,181 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// UnmarshalYAML keeps the raw condition tree next to the decoded one so the
+// definition can be instantiated again with different params by ref nodes.
+// Placeholders like ${max} are replaced by the declared defaults here.
+func (n *NamedCondition) UnmarshalYAML(value *yaml.Node) error {
+	var raw struct {
+		ID        string            `yaml:"id"`
+		Params    map[string]string `yaml:"params"`
+		Condition yaml.Node         `yaml:"condition"`
+	}
+	if err := value.Decode(&raw); err != nil {
+		return err
+	}
+	n.ID = raw.ID
+	n.Params = raw.Params
+	n.body = &raw.Condition
+	if raw.Condition.Kind == 0 {
+		return nil
+	}
+	cond, err := n.instantiate(nil)
+	if err != nil {
+		return fmt.Errorf("condition '%s': %w", n.ID, err)
+	}
+	n.Condition = cond
+	return nil
+}
+
+// instantiate decodes a fresh copy of the definition with params applied
+// over the declared defaults.
+func (n *NamedCondition) instantiate(params map[string]string) (*Condition, error) {
+	values := make(map[string]string, len(n.Params))
+	for name, def := range n.Params {
+		values[name] = def
+	}
+	for name, v := range params {
+		if _, ok := n.Params[name]; !ok {
+			return nil, fmt.Errorf("unknown param '%s' (declared: %s)", name, strings.Join(sortedParamNames(n.Params), ", "))
+		}
+		values[name] = v
+	}
+	body := cloneNode(n.body)
+	if err := substituteParams(body, values); err != nil {
+		return nil, err
+	}
+	var cond Condition
+	if err := body.Decode(&cond); err != nil {
+		return nil, err
+	}
+	return &cond, nil
+}
+
+func sortedParamNames(params map[string]string) []string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return []string{"none"}
+	}
+	return names
+}
+
+func cloneNode(n *yaml.Node) *yaml.Node {
+	if n == nil {
+		return nil
+	}
+	c := *n
+	c.Content = make([]*yaml.Node, len(n.Content))
+	for i, child := range n.Content {
+		c.Content[i] = cloneNode(child)
+	}
+	return &c
+}
+
+var paramPattern = regexp.MustCompile(`\$\{[A-Za-z_][A-Za-z0-9_]*\}`)
+
+// substituteParams replaces ${name} placeholders in scalar values. A scalar
+// that is exactly one placeholder is re-typed, so `code: ${code}` decodes
+// into an int.
+func substituteParams(n *yaml.Node, values map[string]string) error {
+	if n.Kind == yaml.ScalarNode && strings.Contains(n.Value, "${") {
+		whole := strings.HasPrefix(n.Value, "${") && strings.HasSuffix(n.Value, "}") && strings.Count(n.Value, "${") == 1
+		var missing error
+		n.Value = paramPattern.ReplaceAllStringFunc(n.Value, func(m string) string {
+			name := m[2 : len(m)-1]
+			v, ok := values[name]
+			if !ok {
+				missing = fmt.Errorf("line %d: undeclared param '%s'", n.Line, name)
+				return m
+			}
+			return v
+		})
+		if missing != nil {
+			return missing
+		}
+		if whole {
+			n.Tag = ""
+			n.Style = 0
+		}
+	}
+	for _, child := range n.Content {
+		if err := substituteParams(child, values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ConditionSet resolves `ref:` nodes against the named conditions of a config.
+type ConditionSet struct {
+	defs map[string]*NamedCondition
+}
+
+func NewConditionSet(named []NamedCondition) *ConditionSet {
+	s := &ConditionSet{defs: make(map[string]*NamedCondition, len(named))}
+	for i := range named {
+		s.defs[named[i].ID] = &named[i]
+	}
+	return s
+}
+
+// Resolve instantiates every ref in the tree of the named condition id (use
+// "" for trees that are not named, such as inline service conditions).
+// Unknown ids, unknown params and reference cycles are recorded on the ref
+// nodes, where Validate reports them with their position, and returned
+// together.
+func (s *ConditionSet) Resolve(id string, c *Condition) error {
+	var stack []string
+	if id != "" {
+		stack = []string{id}
+	}
+	return errors.Join(s.resolve(c, stack)...)
+}
+
+func (s *ConditionSet) resolve(c *Condition, stack []string) []error {
+	var errs []error
+	c.walk(func(n *Condition) {
+		if n.Ref == "" || n.resolved != nil {
+			return
+		}
+		if err := s.resolveRef(n, stack); err != nil {
+			n.refErr = err.Error()
+			errs = append(errs, err)
+		}
+	})
+	return errs
+}
+
+func (s *ConditionSet) resolveRef(n *Condition, stack []string) error {
+	for _, id := range stack {
+		if id == n.Ref {
+			return fmt.Errorf("reference cycle: %s -> %s", strings.Join(stack, " -> "), n.Ref)
+		}
+	}
+	def, ok := s.defs[n.Ref]
+	if !ok {
+		return fmt.Errorf("unknown condition ref '%s'", n.Ref)
+	}
+	target, err := def.instantiate(n.Params)
+	if err != nil {
+		return fmt.Errorf("ref '%s': %w", n.Ref, err)
+	}
+	if errs := s.resolve(target, append(append([]string(nil), stack...), n.Ref)); len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	n.resolved = target
+	n.refErr = ""
+	return nil
+}
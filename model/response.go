@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	conditionevaluator "healthy-api/evaluator"
+)
+
+// The per-check context types live in package evaluator so condition types
+// registered from outside this package can use them.
+type (
+	ResponseContext = conditionevaluator.Context
+	Timing          = conditionevaluator.Timing
+	RedirectHop     = conditionevaluator.RedirectHop
+	History         = conditionevaluator.History
+	CheckSample     = conditionevaluator.CheckSample
+	BaselineStore   = conditionevaluator.BaselineStore
+)
+
+func NewHistory(maxChecks int, maxAge time.Duration) *History {
+	return conditionevaluator.NewHistory(maxChecks, maxAge)
+}
+
+func NewBaselineStore() *BaselineStore {
+	return conditionevaluator.NewBaselineStore()
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	DefaultRequestTimeout = 15 * time.Second
+	DefaultCheckPeriod    = 60 * time.Second
+)
+
+// RequestTimeout returns the configured timeout or DefaultRequestTimeout.
+func (s *Service) RequestTimeout() time.Duration {
+	if s.Timeout > 0 {
+		return time.Duration(s.Timeout) * time.Second
+	}
+	return DefaultRequestTimeout
+}
+
+// CheckInterval returns the configured check_period or DefaultCheckPeriod.
+func (s *Service) CheckInterval() time.Duration {
+	if s.CheckPeriod > 0 {
+		return time.Duration(s.CheckPeriod) * time.Second
+	}
+	return DefaultCheckPeriod
+}
+
+// FailInterval returns the configured sleep_on_fail or, when it is not set,
+// the check interval.
+func (s *Service) FailInterval() time.Duration {
+	if s.SleepOnFail > 0 {
+		return time.Duration(s.SleepOnFail) * time.Second
+	}
+	return s.CheckInterval()
+}
+
+// DefaultCondition accepts any 2xx response received within timeout.
+func DefaultCondition(timeout time.Duration) *Condition {
+	return &Condition{And: []*Condition{
+		StatusCodeNode(&StatusCodeCondition{Classes: []string{"2xx"}}),
+		ResponseTimeNode(&ResponseTimeCondition{MaxDuration: timeout.String()}),
+	}}
+}
+
+// InlineCondition returns the condition the service carries itself: the
+// inline tree, the expected_status_code shorthand, or DefaultCondition when
+// nothing is configured. It returns nil when the service uses condition_id.
+func (s *Service) InlineCondition() (*Condition, error) {
+	set := 0
+	for _, used := range []bool{s.Condition != nil, s.ConditionName != "", s.ExpectedStatusCode != 0} {
+		if used {
+			set++
+		}
+	}
+	if set > 1 {
+		return nil, fmt.Errorf("service '%s': use only one of condition, condition_id and expected_status_code", s.Name)
+	}
+	switch {
+	case s.Condition != nil:
+		return s.Condition, nil
+	case s.ExpectedStatusCode != 0:
+		return StatusCodeNode(&StatusCodeCondition{Code: s.ExpectedStatusCode}), nil
+	case s.ConditionName != "":
+		return nil, nil
+	}
+	return DefaultCondition(s.RequestTimeout()), nil
+}
+
+// ConditionLabel describes where the service's condition comes from.
+func (s *Service) ConditionLabel() string {
+	switch {
+	case s.ConditionName != "":
+		return s.ConditionName
+	case s.Condition != nil:
+		return "(inline)"
+	case s.ExpectedStatusCode != 0:
+		return fmt.Sprintf("(expected_status_code %d)", s.ExpectedStatusCode)
+	}
+	return "(default: 2xx within timeout)"
+}
@@ -0,0 +1,73 @@
+package model
+
+import "fmt"
+
+// Severity ranks how bad a failed condition is. A node tagged with a
+// severity reports its failures with that severity; untagged leaves fail as
+// critical.
+type Severity string
+
+const (
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+func (s Severity) Validate() error {
+	switch s {
+	case "", SeverityWarning, SeverityCritical:
+		return nil
+	}
+	return fmt.Errorf("unknown severity '%s', use warning or critical", s)
+}
+
+func (s Severity) rank() int {
+	switch s {
+	case SeverityWarning:
+		return 1
+	case SeverityCritical:
+		return 2
+	}
+	return 0
+}
+
+func maxSeverity(a, b Severity) Severity {
+	if b.rank() > a.rank() {
+		return b
+	}
+	return a
+}
+
+// ServiceState is the outcome of one check: UP, DEGRADED when only
+// warning-level conditions failed, or DOWN.
+type ServiceState string
+
+const (
+	StateUp       ServiceState = "UP"
+	StateDegraded ServiceState = "DEGRADED"
+	StateDown     ServiceState = "DOWN"
+)
+
+// State maps the result onto a service state.
+func (r EvaluationResult) State() ServiceState {
+	switch {
+	case r.IsHealthy:
+		return StateUp
+	case r.Severity == SeverityWarning:
+		return StateDegraded
+	}
+	return StateDown
+}
+
+// Accepts reports whether the target wants notifications of the given
+// severity. A target without severities receives everything.
+func (t Target) Accepts(s Severity) bool {
+	if len(t.Severities) == 0 {
+		return true
+	}
+	for _, want := range t.Severities {
+		if want == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package model
+
+// Slack posts alerts as color-coded attachments. With bot_token the
+// notifier uses chat.postMessage and recipients are channel IDs; recoveries
+// are then posted in the thread of the alert. Without it, recipients are
+// incoming webhook URLs.
+type Slack struct {
+	ID       string `yaml:"id"`
+	BotToken string `yaml:"bot_token,omitempty"`
+	// APIBaseURL is https://slack.com/api by default.
+	APIBaseURL string `yaml:"api_base_url,omitempty"`
+	// Template renders the message text in mrkdwn; values are escaped.
+	Template string `yaml:"template,omitempty"`
+}
+
+type SlackMessage struct {
+	Channel     string            `json:"channel,omitempty"`
+	Text        string            `json:"text"`
+	ThreadTS    string            `json:"thread_ts,omitempty"`
+	Attachments []SlackAttachment `json:"attachments,omitempty"`
+}
+
+type SlackAttachment struct {
+	Color    string       `json:"color"`
+	Fields   []SlackField `json:"fields,omitempty"`
+	Footer   string       `json:"footer,omitempty"`
+	TS       int64        `json:"ts,omitempty"`
+	MrkdwnIn []string     `json:"mrkdwn_in,omitempty"`
+}
+
+type SlackField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+type SlackResponse struct {
+	OK      bool   `json:"ok"`
+	Error   string `json:"error,omitempty"`
+	Channel string `json:"channel,omitempty"`
+	TS      string `json:"ts,omitempty"`
+}
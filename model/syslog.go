@@ -0,0 +1,32 @@
+package model
+
+// Syslog emits every alert as an RFC 5424 message to a syslog collector or
+// SIEM. It has no recipients; Address is the only destination.
+type Syslog struct {
+	ID string `yaml:"id"`
+	// Network is udp (RFC 5426), tcp (RFC 6587 octet counting) or tls
+	// (RFC 5425); udp by default.
+	Network string `yaml:"network,omitempty"`
+	Address string `yaml:"address"` // host:port
+	// Facility is kern, user, daemon, auth, authpriv, syslog, local0 to
+	// local7 and the like; daemon by default.
+	Facility string `yaml:"facility,omitempty"`
+	// Hostname and AppName fill the HEADER; the machine's host name and
+	// healthy-api by default.
+	Hostname string `yaml:"hostname,omitempty"`
+	AppName  string `yaml:"app_name,omitempty"`
+	// SDID names the structured data element; private IDs take the
+	// name@<enterprise number> form. healthcheck@32473 by default.
+	SDID string     `yaml:"sd_id,omitempty"`
+	TLS  *SyslogTLS `yaml:"tls,omitempty"`
+}
+
+// SyslogTLS configures the tls network. The system roots verify the
+// collector unless CAFile is given.
+type SyslogTLS struct {
+	CAFile             string `yaml:"ca_file,omitempty"`
+	CertFile           string `yaml:"cert_file,omitempty"`
+	KeyFile            string `yaml:"key_file,omitempty"`
+	ServerName         string `yaml:"server_name,omitempty"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify,omitempty"`
+}
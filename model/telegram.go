@@ -0,0 +1,39 @@
+package model
+
+// Telegram is a bot that sends alerts with the Bot API sendMessage method.
+// Recipients are chat IDs (e.g. "123456", "-1001234567890" or "@channel").
+type Telegram struct {
+	ID       string `yaml:"id"`
+	BotToken string `yaml:"bot_token"`
+	// APIBaseURL points to a self-hosted Bot API server or a test stand-in;
+	// https://api.telegram.org by default.
+	APIBaseURL string `yaml:"api_base_url,omitempty"`
+	// ParseMode is MarkdownV2, HTML or empty for plain text. Template values
+	// are escaped for it, so templates only need to escape their own text.
+	ParseMode string `yaml:"parse_mode,omitempty"`
+	Template  string `yaml:"template,omitempty"`
+	// SilentWarnings sends warning (DEGRADED) alerts without sound; true by
+	// default.
+	SilentWarnings *bool `yaml:"silent_warnings,omitempty"`
+}
+
+type TelegramSendMessageRequest struct {
+	ChatID              string                      `json:"chat_id"`
+	Text                string                      `json:"text"`
+	ParseMode           string                      `json:"parse_mode,omitempty"`
+	DisableNotification bool                        `json:"disable_notification,omitempty"`
+	LinkPreviewOptions  *TelegramLinkPreviewOptions `json:"link_preview_options,omitempty"`
+}
+
+type TelegramLinkPreviewOptions struct {
+	IsDisabled bool `json:"is_disabled"`
+}
+
+type TelegramResponse struct {
+	OK          bool   `json:"ok"`
+	ErrorCode   int    `json:"error_code,omitempty"`
+	Description string `json:"description,omitempty"`
+	Parameters  *struct {
+		RetryAfter int `json:"retry_after,omitempty"`
+	} `json:"parameters,omitempty"`
+}
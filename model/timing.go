@@ -0,0 +1,110 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	conditionevaluator "healthy-api/evaluator"
+)
+
+// TimingCondition puts an upper limit on individual request phases. Every
+// phase is optional.
+//
+//	timing:
+//	  dns: 50ms
+//	  ttfb: 400ms
+type TimingCondition struct {
+	DNS      string `yaml:"dns,omitempty"`
+	Connect  string `yaml:"connect,omitempty"`
+	TLS      string `yaml:"tls,omitempty"`
+	TTFB     string `yaml:"ttfb,omitempty"`
+	Download string `yaml:"download,omitempty"`
+
+	max []time.Duration // parsed limits in limits() order, 0 when unset
+}
+
+type timingLimit struct {
+	phase  string
+	limit  string
+	actual time.Duration
+}
+
+func (tc *TimingCondition) limits(t Timing) []timingLimit {
+	return []timingLimit{
+		{"DNS lookup", tc.DNS, t.DNS},
+		{"TCP connect", tc.Connect, t.Connect},
+		{"TLS handshake", tc.TLS, t.TLS},
+		{"Time to first byte", tc.TTFB, t.TTFB},
+		{"Body download", tc.Download, t.Download},
+	}
+}
+
+func (tc *TimingCondition) parse() ([]time.Duration, error) {
+	limits := tc.limits(Timing{})
+	max := make([]time.Duration, len(limits))
+	for i, l := range limits {
+		if l.limit == "" {
+			continue
+		}
+		d, err := time.ParseDuration(l.limit)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s duration '%s': %v", l.phase, l.limit, err)
+		}
+		max[i] = d
+	}
+	return max, nil
+}
+
+func (tc *TimingCondition) Validate() error {
+	max, err := tc.parse()
+	if err != nil {
+		return err
+	}
+	if tc.DNS == "" && tc.Connect == "" && tc.TLS == "" && tc.TTFB == "" && tc.Download == "" {
+		return fmt.Errorf("at least one of dns, connect, tls, ttfb or download is required")
+	}
+	tc.max = max
+	return nil
+}
+
+func (tc *TimingCondition) Evaluate(ctx *ResponseContext) conditionevaluator.Result {
+	res := tc.evaluate(ctx.Timing)
+	res.Expected, res.Actual = tc.String(), ctx.Timing.String()
+	return res
+}
+
+func (tc *TimingCondition) evaluate(t Timing) conditionevaluator.Result {
+	limits := tc.max
+	if limits == nil {
+		var err error
+		if limits, err = tc.parse(); err != nil {
+			return conditionevaluator.Result{IsHealthy: false, Reason: fmt.Sprintf("Invalid timing condition: %v", err)}
+		}
+	}
+	for i, l := range tc.limits(t) {
+		if l.limit == "" {
+			continue
+		}
+		max := limits[i]
+		if l.actual > max {
+			return conditionevaluator.Result{
+				IsHealthy: false,
+				Reason:    fmt.Sprintf("%s took %v, exceeded limit %v", l.phase, l.actual.Round(time.Millisecond), max),
+			}
+		}
+	}
+	return conditionevaluator.Result{IsHealthy: true}
+}
+
+func (tc *TimingCondition) String() string {
+	var parts []string
+	for _, name := range []struct{ key, limit string }{
+		{"dns", tc.DNS}, {"connect", tc.Connect}, {"tls", tc.TLS}, {"ttfb", tc.TTFB}, {"download", tc.Download},
+	} {
+		if name.limit != "" {
+			parts = append(parts, fmt.Sprintf("%s<=%s", name.key, name.limit))
+		}
+	}
+	return strings.Join(parts, " ")
+}
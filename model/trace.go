@@ -0,0 +1,104 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TraceNode records how one condition node was evaluated. The tree mirrors
+// the condition tree; children that were not evaluated because an `and`
+// already failed are kept with Skipped set.
+type TraceNode struct {
+	Type     ConditionType `json:"type"`
+	Path     string        `json:"path"`
+	Passed   bool          `json:"passed"`
+	Skipped  bool          `json:"skipped,omitempty"`
+	Actual   string        `json:"actual,omitempty"`
+	Expected string        `json:"expected,omitempty"`
+	Reason   string        `json:"reason,omitempty"`
+	Severity Severity      `json:"severity,omitempty"`
+	Duration time.Duration `json:"duration"`
+	Children []*TraceNode  `json:"children,omitempty"`
+}
+
+// String renders the tree as indented text, one line per node:
+//
+//	FAIL and condition (1ms)
+//	  PASS status_code condition.and[0]: expected 200, actual 200
+//	  FAIL response_time condition.and[1]: expected <= 500ms, actual 812ms
+//	    Response time 812ms exceeded limit 500ms
+func (t *TraceNode) String() string {
+	if t == nil {
+		return ""
+	}
+	var sb strings.Builder
+	t.render(&sb, 0)
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func (t *TraceNode) render(sb *strings.Builder, depth int) {
+	indent := strings.Repeat("  ", depth)
+	status := "FAIL"
+	switch {
+	case t.Skipped:
+		status = "SKIP"
+	case t.Passed:
+		status = "PASS"
+	case t.Severity == SeverityWarning:
+		status = "WARN"
+	}
+	fmt.Fprintf(sb, "%s%s %s %s", indent, status, t.Type, t.Path)
+	if t.Expected != "" || t.Actual != "" {
+		fmt.Fprintf(sb, ": expected %s, actual %s", orDash(t.Expected), orDash(t.Actual))
+	}
+	if !t.Skipped {
+		fmt.Fprintf(sb, " (%v)", t.Duration.Round(time.Microsecond))
+	}
+	sb.WriteString("\n")
+	if !t.Passed && !t.Skipped && len(t.Children) == 0 && t.Reason != "" {
+		reason := strings.ReplaceAll(t.Reason, "\n", "\n"+indent+"  ")
+		fmt.Fprintf(sb, "%s  %s\n", indent, reason)
+	}
+	for _, c := range t.Children {
+		c.render(sb, depth+1)
+	}
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// JSON returns the tree encoded as a single JSON document, for logs.
+func (t *TraceNode) JSON() string {
+	if t == nil {
+		return ""
+	}
+	data, err := json.Marshal(t)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// Type reports which kind of node c is.
+func (c *Condition) Type() ConditionType {
+	if c.Evaluator != nil {
+		return ConditionType(c.EvaluatorKey)
+	}
+	switch {
+	case c.And != nil:
+		return ConditionAnd
+	case c.Or != nil:
+		return ConditionOr
+	case c.Not != nil:
+		return ConditionNot
+	case c.Ref != "":
+		return ConditionRef
+	}
+	return ""
+}
@@ -0,0 +1,187 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	conditionevaluator "healthy-api/evaluator"
+)
+
+// ValidationError is one problem found in a condition tree. Line and Column
+// point into the YAML source when the condition was loaded from a file.
+type ValidationError struct {
+	File   string
+	Line   int
+	Column int
+	Path   string
+	Msg    string
+}
+
+func (e ValidationError) Error() string {
+	var pos string
+	switch {
+	case e.File != "" && e.Line > 0 && e.Column > 0:
+		pos = fmt.Sprintf("%s:%d:%d: ", e.File, e.Line, e.Column)
+	case e.File != "" && e.Line > 0:
+		pos = fmt.Sprintf("%s:%d: ", e.File, e.Line)
+	case e.File != "":
+		pos = e.File + ": "
+	case e.Line > 0:
+		pos = fmt.Sprintf("line %d:%d: ", e.Line, e.Column)
+	}
+	return fmt.Sprintf("%s%s: %s", pos, e.Path, e.Msg)
+}
+
+// ValidationErrors collects every problem of a tree instead of only the
+// first one.
+type ValidationErrors []ValidationError
+
+func (errs ValidationErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// InFile returns a copy of errs attributed to the given config file.
+func (errs ValidationErrors) InFile(file string) ValidationErrors {
+	out := make(ValidationErrors, len(errs))
+	for i, e := range errs {
+		e.File = file
+		out[i] = e
+	}
+	return out
+}
+
+// UnmarshalYAML builds registered condition types through their factories
+// and records where the node is defined so validation errors can point at it.
+// Problems are kept on the node rather than returned, so that one bad node
+// does not stop the rest of the file from being checked; Validate reports
+// them all.
+func (c *Condition) UnmarshalYAML(value *yaml.Node) error {
+	c.line, c.column = value.Line, value.Column
+	if value.Kind == yaml.MappingNode {
+		rest := *value
+		rest.Content = nil
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			key, val := value.Content[i], value.Content[i+1]
+			factory, ok := conditionevaluator.Lookup(key.Value)
+			if !ok {
+				rest.Content = append(rest.Content, key, val)
+				continue
+			}
+			if c.Evaluator != nil {
+				c.decodeError(key, "a condition node must contain exactly one field, got both %s and %s", c.EvaluatorKey, key.Value)
+				continue
+			}
+			ev, err := factory(val)
+			if err != nil {
+				c.decodeError(key, "%s: %v", key.Value, err)
+				continue
+			}
+			c.Evaluator, c.EvaluatorKey = ev, key.Value
+		}
+		value = &rest
+	}
+	type plain Condition
+	if err := value.Decode((*plain)(c)); err != nil {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			c.decodeError(value, "%v", err)
+			return nil
+		}
+		// Type errors read "line N: ..."; keep the line as the position.
+		for _, msg := range typeErr.Errors {
+			e := ValidationError{Line: value.Line, Msg: msg}
+			if line, text, ok := strings.Cut(msg, ": "); ok {
+				if _, err := fmt.Sscanf(line, "line %d", &e.Line); err == nil {
+					e.Msg = text
+				}
+			}
+			c.decodeErrs = append(c.decodeErrs, e)
+		}
+	}
+	return nil
+}
+
+func (c *Condition) decodeError(at *yaml.Node, format string, args ...any) {
+	c.decodeErrs = append(c.decodeErrs, ValidationError{Line: at.Line, Column: at.Column, Msg: fmt.Sprintf(format, args...)})
+}
+
+// Validate checks the whole tree, compiling regexes, schemas and
+// expressions and parsing durations once so evaluation can reuse them. The
+// returned error is a ValidationErrors listing every problem found.
+func (c *Condition) Validate(path string) error {
+	var errs ValidationErrors
+	c.validate(path, &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (c *Condition) validate(path string, errs *ValidationErrors) {
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, ValidationError{Line: c.line, Column: c.column, Path: path, Msg: fmt.Sprintf(format, args...)})
+	}
+	check := func(name string, err error) {
+		if err != nil {
+			fail("invalid %s: %v", name, err)
+		}
+	}
+
+	for _, e := range c.decodeErrs {
+		e.Path = path
+		*errs = append(*errs, e)
+	}
+
+	count := 0
+	for _, set := range []bool{
+		c.And != nil, c.Or != nil, c.Not != nil, c.Evaluator != nil, c.Ref != "",
+	} {
+		if set {
+			count++
+		}
+	}
+	if count != 1 && (count != 0 || len(c.decodeErrs) == 0) {
+		fail("a condition node must contain exactly one field (got %d)", count)
+	}
+	if c.Params != nil && c.Ref == "" {
+		fail("params can only be used together with ref")
+	}
+	if err := c.Severity.Validate(); err != nil {
+		fail("%v", err)
+	}
+
+	if c.Evaluator != nil {
+		check(c.EvaluatorKey, c.Evaluator.Validate())
+	}
+	children := func(kind string, list []*Condition) {
+		for i, child := range list {
+			childPath := fmt.Sprintf("%s.%s[%d]", path, kind, i)
+			if child == nil {
+				*errs = append(*errs, ValidationError{Line: c.line, Column: c.column, Path: childPath, Msg: "empty condition"})
+				continue
+			}
+			child.validate(childPath, errs)
+		}
+	}
+	children("and", c.And)
+	children("or", c.Or)
+	if c.Not != nil {
+		c.Not.validate(path+".not", errs)
+	}
+	if c.Ref != "" {
+		if c.resolved == nil && c.refErr != "" {
+			fail("%s", c.refErr)
+		} else if c.resolved == nil {
+			fail("condition ref '%s' is not resolved", c.Ref)
+		} else {
+			c.resolved.validate(path+"->"+c.Ref, errs)
+		}
+	}
+}
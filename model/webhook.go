@@ -11,4 +11,8 @@ type WebhookTemplate struct {
 	ServiceName string
 	TimeStamp   string
 	URL         string
+	Timing      string
+	Trace       string
+	State       string
+	Severity    string
 }
@@ -0,0 +1,278 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	conditionevaluator "healthy-api/evaluator"
+)
+
+// WindowCondition evaluates a statistic over the service's recent checks
+// instead of the current response alone, e.g.
+//
+//	window:
+//	  metric: p95_response_time
+//	  operator: "<"
+//	  value: 800ms
+//	  checks: 20
+//
+// Supported metrics are avg_response_time, max_response_time,
+// pNN_response_time (p50, p95, p99, ...), success_rate (value in percent)
+// and error_count. A window covers the last `checks` checks, the last
+// `period`, or both when both are set.
+type WindowCondition struct {
+	Metric     string `yaml:"metric"`
+	Operator   string `yaml:"operator"`
+	Value      string `yaml:"value"`
+	Checks     int    `yaml:"checks,omitempty"`
+	Period     string `yaml:"period,omitempty"`
+	MinSamples int    `yaml:"min_samples,omitempty"` // pass until this many samples exist
+
+	spec *windowSpec
+}
+
+type windowMetric int
+
+const (
+	metricResponseTime windowMetric = iota
+	metricSuccessRate
+	metricErrorCount
+)
+
+// windowSpec is the parsed form of a WindowCondition.
+type windowSpec struct {
+	kind       windowMetric
+	percentile float64
+	threshold  float64
+	period     time.Duration
+}
+
+// compiled returns the spec cached by Validate, parsing it when Validate
+// was not called.
+func (w *WindowCondition) compiled() (windowSpec, error) {
+	if w.spec != nil {
+		return *w.spec, nil
+	}
+	return w.parse()
+}
+
+func (w *WindowCondition) parse() (spec windowSpec, err error) {
+	m := strings.ToLower(w.Metric)
+	switch {
+	case m == "success_rate":
+		spec.kind = metricSuccessRate
+		spec.threshold, err = strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(w.Value), "%"), 64)
+		if err != nil || spec.threshold < 0 || spec.threshold > 100 {
+			return spec, fmt.Errorf("success_rate needs a percentage between 0 and 100, got '%s'", w.Value)
+		}
+	case m == "error_count":
+		spec.kind = metricErrorCount
+		n, convErr := strconv.Atoi(strings.TrimSpace(w.Value))
+		if convErr != nil || n < 0 {
+			return spec, fmt.Errorf("error_count needs a non-negative integer, got '%s'", w.Value)
+		}
+		spec.threshold = float64(n)
+	case strings.HasSuffix(m, "_response_time"):
+		spec.kind = metricResponseTime
+		switch name := strings.TrimSuffix(m, "_response_time"); {
+		case name == "avg":
+			spec.percentile = -1
+		case name == "max":
+			spec.percentile = 100
+		case strings.HasPrefix(name, "p"):
+			spec.percentile, err = strconv.ParseFloat(name[1:], 64)
+			if err != nil || spec.percentile <= 0 || spec.percentile > 100 {
+				return spec, fmt.Errorf("unknown percentile in metric '%s'", w.Metric)
+			}
+		default:
+			return spec, fmt.Errorf("unknown metric '%s'", w.Metric)
+		}
+		d, parseErr := time.ParseDuration(strings.TrimSpace(w.Value))
+		if parseErr != nil {
+			return spec, fmt.Errorf("%s needs a duration value, got '%s'", w.Metric, w.Value)
+		}
+		spec.threshold = float64(d)
+	default:
+		return spec, fmt.Errorf("unknown metric '%s'", w.Metric)
+	}
+	if w.Period != "" {
+		if spec.period, err = time.ParseDuration(w.Period); err != nil || spec.period <= 0 {
+			return spec, fmt.Errorf("invalid period '%s'", w.Period)
+		}
+	}
+	return spec, nil
+}
+
+func (w *WindowCondition) Validate() error {
+	spec, err := w.parse()
+	if err != nil {
+		return err
+	}
+	switch w.Operator {
+	case "<", "<=", ">", ">=", "==":
+	default:
+		return fmt.Errorf("unknown operator '%s', use one of <, <=, >, >=, ==", w.Operator)
+	}
+	if w.Checks < 0 || w.MinSamples < 0 {
+		return fmt.Errorf("checks and min_samples cannot be negative")
+	}
+	if w.Checks == 0 && w.Period == "" {
+		return fmt.Errorf("either checks or period is required")
+	}
+	w.spec = &spec
+	return nil
+}
+
+func (w *WindowCondition) Evaluate(ctx *ResponseContext) conditionevaluator.Result {
+	res := w.evaluate(ctx)
+	res.Expected = fmt.Sprintf("%s %s %s", w.Metric, w.Operator, w.Value)
+	return res
+}
+
+func (w *WindowCondition) evaluate(ctx *ResponseContext) conditionevaluator.Result {
+	// Without history (the first pass that decides whether a check counts
+	// as a success) window conditions do not take part.
+	if ctx.History == nil {
+		return conditionevaluator.Result{IsHealthy: true}
+	}
+	spec, err := w.compiled()
+	if err != nil {
+		return conditionevaluator.Result{IsHealthy: false, Reason: fmt.Sprintf("Invalid window condition: %v", err)}
+	}
+	samples := ctx.History.Window(w.Checks, spec.period, time.Now())
+	if len(samples) == 0 || len(samples) < w.MinSamples {
+		return conditionevaluator.Result{IsHealthy: true}
+	}
+
+	var actual float64
+	var actualStr string
+	switch spec.kind {
+	case metricSuccessRate:
+		ok := 0
+		for _, s := range samples {
+			if s.Success {
+				ok++
+			}
+		}
+		actual = float64(ok) * 100 / float64(len(samples))
+		actualStr = strconv.FormatFloat(actual, 'f', 1, 64) + "%"
+	case metricErrorCount:
+		for _, s := range samples {
+			if !s.Success {
+				actual++
+			}
+		}
+		actualStr = strconv.Itoa(int(actual))
+	case metricResponseTime:
+		var durations []time.Duration
+		for _, s := range samples {
+			if s.StatusCode != 0 {
+				durations = append(durations, s.Duration)
+			}
+		}
+		if len(durations) == 0 {
+			return conditionevaluator.Result{IsHealthy: true}
+		}
+		d := aggregateDurations(durations, spec.percentile)
+		actual = float64(d)
+		actualStr = d.Round(time.Millisecond).String()
+	}
+
+	if compareFloat(actual, w.Operator, spec.threshold) {
+		return conditionevaluator.Result{IsHealthy: true}
+	}
+	return conditionevaluator.Result{
+		IsHealthy: false,
+		Reason:    fmt.Sprintf("%s %s is %s, expected %s %s", w.Metric, w.describeWindow(len(samples)), actualStr, w.Operator, w.Value),
+	}
+}
+
+func (w *WindowCondition) describeWindow(n int) string {
+	switch {
+	case w.Checks > 0 && w.Period != "":
+		return fmt.Sprintf("over last %d checks within %s (%d samples)", w.Checks, w.Period, n)
+	case w.Period != "":
+		return fmt.Sprintf("over %s (%d samples)", w.Period, n)
+	}
+	return fmt.Sprintf("over last %d checks", n)
+}
+
+// aggregateDurations returns the average for percentile < 0, otherwise the
+// nearest-rank percentile.
+func aggregateDurations(durations []time.Duration, percentile float64) time.Duration {
+	if percentile < 0 {
+		var sum time.Duration
+		for _, d := range durations {
+			sum += d
+		}
+		return sum / time.Duration(len(durations))
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := int(math.Ceil(percentile / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func compareFloat(a float64, op string, b float64) bool {
+	switch op {
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	case "==":
+		return a == b
+	}
+	return false
+}
+
+// HistoryWindow implements conditionevaluator.Windowed.
+func (w *WindowCondition) HistoryWindow() (checks int, period time.Duration) {
+	if spec, err := w.compiled(); err == nil {
+		period = spec.period
+	}
+	return w.Checks, period
+}
+
+// HistoryWindow reports how much check history the condition tree needs:
+// the largest `checks` and `period` of its nodes that read the history.
+// used is false when the tree has none.
+func (c *Condition) HistoryWindow() (checks int, period time.Duration, used bool) {
+	c.walk(func(n *Condition) {
+		w, ok := n.Evaluator.(conditionevaluator.Windowed)
+		if !ok {
+			return
+		}
+		used = true
+		nodeChecks, nodePeriod := w.HistoryWindow()
+		checks = max(checks, nodeChecks)
+		period = max(period, nodePeriod)
+	})
+	return checks, period, used
+}
+
+// walk calls fn for every node of the tree.
+func (c *Condition) walk(fn func(*Condition)) {
+	if c == nil {
+		return
+	}
+	fn(c)
+	for _, child := range c.And {
+		child.walk(fn)
+	}
+	for _, child := range c.Or {
+		child.walk(fn)
+	}
+	c.Not.walk(fn)
+	c.resolved.walk(fn)
+}
@@ -0,0 +1,75 @@
+package notifier_test
+
+import (
+	"healthy-api/model"
+	"healthy-api/notifier"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestBaleNotifier_IsolatesValuesInPersianText(t *testing.T) {
+	var received model.TelegramSendMessageRequest
+	server := newTelegramServer(t, func(w http.ResponseWriter, req model.TelegramSendMessageRequest) {
+		received = req
+		w.Write([]byte(`{"ok":true,"result":{}}`))
+	})
+	bl, err := notifier.NewBaleNotifier(model.Bale{ID: "bale", BotToken: "123:abc", APIBaseURL: server.URL},
+		slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bl.GetName() != "BaleNotifier(bale)" {
+		t.Errorf("unexpected name %s", bl.GetName())
+	}
+
+	err = bl.Notify(model.Notification{
+		ServiceName:  "user-service",
+		Recipients:   []string{"4242"},
+		Reason:       "Status code 503\nexpected 200",
+		StatusCode:   503,
+		ResponseTime: "1.2s",
+		State:        model.StateDown,
+		Severity:     model.SeverityWarning,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if received.ChatID != "4242" || received.ParseMode != "" || received.DisableNotification || received.LinkPreviewOptions != nil {
+		t.Errorf("unexpected request: %+v", received)
+	}
+	for _, want := range []string{
+		"سرویس ⁨user-service⁩ در وضعیت ⁨DOWN⁩ است",
+		"علت: ⁨Status code 503⁩\n⁨expected 200⁩\n",
+		"زمان پاسخ: ⁨1.2s⁩",
+	} {
+		if !strings.Contains(received.Text, want) {
+			t.Errorf("message %q does not contain %q", received.Text, want)
+		}
+	}
+}
+
+func TestBaleNotifier_ReportsAPIErrors(t *testing.T) {
+	server := newTelegramServer(t, func(w http.ResponseWriter, req model.TelegramSendMessageRequest) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"ok":false,"error_code":400,"description":"chat not found"}`))
+	})
+	bl, err := notifier.NewBaleNotifier(model.Bale{ID: "bale", BotToken: "123:abc", APIBaseURL: server.URL, Template: "{{.ServiceName}}"},
+		slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = bl.Notify(model.Notification{ServiceName: "api", Recipients: []string{"1"}, State: model.StateDown})
+	if err == nil || !strings.Contains(err.Error(), "bale error 400: chat not found") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestNewBaleNotifier_RequiresToken(t *testing.T) {
+	if _, err := notifier.NewBaleNotifier(model.Bale{ID: "bale"}, slog.New(slog.NewTextHandler(io.Discard, nil))); err == nil {
+		t.Fatal("expected an error without bot_token")
+	}
+}
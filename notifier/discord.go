@@ -0,0 +1,154 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"healthy-api/model"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Discord embed limits, in characters.
+const (
+	discordMaxTitle       = 256
+	discordMaxDescription = 4096
+	discordMaxFieldValue  = 1024
+)
+
+var discordColors = map[model.ServiceState]int{
+	model.StateUp:       0x2eb886,
+	model.StateDegraded: 0xdaa038,
+	model.StateDown:     0xa30200,
+}
+
+// discordReplacer escapes Discord markdown.
+var discordReplacer = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "~", `\~`, "`", "\\`", "|", `\|`, ">", `\>`,
+	"#", `\#`, "-", `\-`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`,
+)
+
+// DiscordNotifier posts embeds to Discord webhooks. Recipients are webhook
+// URLs.
+type DiscordNotifier struct {
+	ID        string
+	Username  string
+	AvatarURL string
+	Title     *MessageTemplate
+	Client    *http.Client
+	Logger    *slog.Logger
+}
+
+func NewDiscordNotifier(cfg model.Discord, logger *slog.Logger) (*DiscordNotifier, error) {
+	title := cfg.Title
+	if title == "" {
+		title = HeadlineTemplate
+	}
+	tmpl, err := NewMessageTemplate(title, MarkupPlain)
+	if err != nil {
+		return nil, fmt.Errorf("invalid title: %w", err)
+	}
+	return &DiscordNotifier{
+		ID:        cfg.ID,
+		Username:  cfg.Username,
+		AvatarURL: cfg.AvatarURL,
+		Title:     tmpl,
+		Client:    newHTTPClient(),
+		Logger:    logger,
+	}, nil
+}
+
+func (d *DiscordNotifier) GetName() string {
+	return fmt.Sprintf("DiscordNotifier(%s)", d.ID)
+}
+
+// Resolve posts the recovery as a green embed.
+func (d *DiscordNotifier) Resolve(n model.Notification) error {
+	return d.Notify(n)
+}
+
+func (d *DiscordNotifier) Notify(n model.Notification) error {
+	msg, err := d.message(n)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal discord message: %w", err)
+	}
+	attrs := []any{"service", n.ServiceName, "state", n.State}
+	return sendToEach(d.Logger, d.ID, "webhook", n.Recipients, "discord_send_failed", "discord_sent", attrs, func(hookURL string) error {
+		return d.send(hookURL, body)
+	})
+}
+
+func (d *DiscordNotifier) message(n model.Notification) (model.DiscordWebhookMessage, error) {
+	title, err := d.Title.Render(n)
+	if err != nil {
+		return model.DiscordWebhookMessage{}, fmt.Errorf("failed to render discord title: %w", err)
+	}
+	var fields []model.DiscordEmbedField
+	if n.StatusCode != 0 {
+		fields = append(fields, model.DiscordEmbedField{Name: "Status code", Value: strconv.Itoa(n.StatusCode), Inline: true})
+	}
+	if n.ResponseTime != "" {
+		fields = append(fields, model.DiscordEmbedField{Name: "Response time", Value: n.ResponseTime, Inline: true})
+	}
+	if n.Severity != "" {
+		fields = append(fields, model.DiscordEmbedField{Name: "Severity", Value: string(n.Severity), Inline: true})
+	}
+	if trace := n.Trace.String(); trace != "" {
+		// Inside a code block only backticks need care; the fences take 8
+		// characters of the field.
+		trace = strings.ReplaceAll(trace, "```", "'''")
+		fields = append(fields, model.DiscordEmbedField{Name: "Trace", Value: "```\n" + truncate(trace, discordMaxFieldValue-8) + "\n```"})
+	}
+	color, ok := discordColors[n.State]
+	if !ok {
+		color = discordColors[model.StateDown]
+	}
+	return model.DiscordWebhookMessage{
+		Username:  d.Username,
+		AvatarURL: d.AvatarURL,
+		Embeds: []model.DiscordEmbed{{
+			Title:       truncate(title, discordMaxTitle),
+			Description: truncate(discordReplacer.Replace(truncate(n.Reason, maxMessageReason)), discordMaxDescription),
+			Color:       color,
+			Fields:      fields,
+			Footer:      &model.DiscordEmbedFooter{Text: "Healthy-API"},
+			Timestamp:   time.Now().UTC().Format(time.RFC3339),
+		}},
+	}, nil
+}
+
+func (d *DiscordNotifier) send(hookURL string, body []byte) error {
+	status, data, err := sendWithRetry(d.Client, d.Logger, d.GetName(), func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPost, hookURL, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	}, discordRetryAfter)
+	if err != nil {
+		return err
+	}
+	if status < 200 || status >= 300 {
+		return fmt.Errorf("status %d: %s", status, truncate(strings.TrimSpace(string(data)), 200))
+	}
+	return nil
+}
+
+// discordRetryAfter reads retry_after (seconds, fractional) from a 429 body.
+func discordRetryAfter(body []byte) time.Duration {
+	var result struct {
+		RetryAfter float64 `json:"retry_after"`
+	}
+	if json.Unmarshal(body, &result) != nil {
+		return 0
+	}
+	return time.Duration(result.RetryAfter * float64(time.Second))
+}
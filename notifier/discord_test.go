@@ -0,0 +1,85 @@
+package notifier_test
+
+import (
+	"encoding/json"
+	"healthy-api/model"
+	"healthy-api/notifier"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestDiscordNotifier_Embed(t *testing.T) {
+	var received model.DiscordWebhookMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("failed to decode request body: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	dc, err := notifier.NewDiscordNotifier(model.Discord{ID: "discord", Username: "healthy-api"}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = dc.Notify(model.Notification{
+		ServiceName:  "user_service",
+		Recipients:   []string{server.URL},
+		Reason:       "Body changed: *drift*",
+		StatusCode:   200,
+		ResponseTime: "80ms",
+		State:        model.StateDown,
+		Trace:        &model.TraceNode{Type: "content_drift", Path: "condition", Reason: strings.Repeat("x", 3000)},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if received.Username != "healthy-api" || len(received.Embeds) != 1 {
+		t.Fatalf("unexpected message: %+v", received)
+	}
+	embed := received.Embeds[0]
+	if embed.Title != "🔴 user_service is DOWN" {
+		t.Errorf("unexpected title %q", embed.Title)
+	}
+	if embed.Description != `Body changed: \*drift\*` {
+		t.Errorf("unexpected description %q", embed.Description)
+	}
+	if embed.Color != 0xa30200 || embed.Timestamp == "" {
+		t.Errorf("unexpected color or timestamp: %+v", embed)
+	}
+	for _, f := range embed.Fields {
+		if utf8.RuneCountInString(f.Value) > 1024 {
+			t.Errorf("field %s is longer than 1024 characters", f.Name)
+		}
+	}
+}
+
+func TestDiscordNotifier_RetriesAfter429(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"message":"You are being rate limited.","retry_after":0.2,"global":false}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	dc, err := notifier.NewDiscordNotifier(model.Discord{ID: "discord"}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := dc.Notify(model.Notification{ServiceName: "api", Recipients: []string{server.URL}, State: model.StateDown}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 calls, got %d", calls)
+	}
+}
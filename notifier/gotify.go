@@ -0,0 +1,144 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"healthy-api/model"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+const gotifyDefaultPriority = 4
+
+// GotifyNotifier sends messages to a Gotify server.
+type GotifyNotifier struct {
+	ID         string
+	ServerURL  string
+	AppToken   string
+	Auth       model.PushAuth
+	Priorities map[model.Severity]int
+	ClickURL   string
+	Title      *MessageTemplate
+	Message    *MessageTemplate
+	Client     *http.Client
+	Logger     *slog.Logger
+}
+
+func NewGotifyNotifier(cfg model.Gotify, logger *slog.Logger) (*GotifyNotifier, error) {
+	if cfg.ServerURL == "" {
+		return nil, fmt.Errorf("server_url is required")
+	}
+	priorities, err := pushPriorities(map[model.Severity]int{
+		model.SeverityCritical: 8,
+		model.SeverityWarning:  5,
+	}, cfg.Priorities, 0, 10)
+	if err != nil {
+		return nil, err
+	}
+	// Gotify has no tags, so the title carries the state's emoji.
+	title, err := NewMessageTemplate(HeadlineTemplate, MarkupPlain)
+	if err != nil {
+		return nil, err
+	}
+	text := cfg.Template
+	if text == "" {
+		text = pushMessageTemplate
+	}
+	message, err := NewMessageTemplate(text, MarkupPlain)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return &GotifyNotifier{
+		ID:         cfg.ID,
+		ServerURL:  strings.TrimRight(cfg.ServerURL, "/"),
+		AppToken:   cfg.AppToken,
+		Auth:       cfg.PushAuth,
+		Priorities: priorities,
+		ClickURL:   cfg.ClickURL,
+		Title:      title,
+		Message:    message,
+		Client:     newHTTPClient(),
+		Logger:     logger,
+	}, nil
+}
+
+func (g *GotifyNotifier) GetName() string {
+	return fmt.Sprintf("GotifyNotifier(%s)", g.ID)
+}
+
+// Resolve sends the recovery at a low priority.
+func (g *GotifyNotifier) Resolve(n model.Notification) error {
+	return g.Notify(n)
+}
+
+func (g *GotifyNotifier) Notify(n model.Notification) error {
+	title, err := g.Title.Render(n)
+	if err != nil {
+		return err
+	}
+	message, err := g.Message.Render(n)
+	if err != nil {
+		return fmt.Errorf("failed to render gotify message: %w", err)
+	}
+	priority := gotifyDefaultPriority
+	if n.State != model.StateUp {
+		priority = g.priority(n.Severity)
+	}
+	msg := model.GotifyMessage{Title: title, Message: message, Priority: priority}
+	click := g.ClickURL
+	if click == "" {
+		click = n.URL
+	}
+	if click != "" {
+		msg.Extras = map[string]any{
+			"client::notification": map[string]any{"click": map[string]string{"url": click}},
+		}
+	}
+
+	tokens := n.Recipients
+	if len(tokens) == 0 && g.AppToken != "" {
+		tokens = []string{g.AppToken}
+	}
+	if len(tokens) == 0 {
+		err := fmt.Errorf("no app token: the target has no recipients and app_token is not set")
+		g.Logger.Error("gotify_send_failed", "notifier", g.ID, "service", n.ServiceName, "error", err)
+		return err
+	}
+	attrs := []any{"service", n.ServiceName, "priority", priority}
+	return sendToEach(g.Logger, g.ID, "app_token", tokens, "gotify_send_failed", "gotify_sent", attrs, func(token string) error {
+		return g.send(token, msg)
+	})
+}
+
+func (g *GotifyNotifier) priority(s model.Severity) int {
+	if p, ok := g.Priorities[s]; ok {
+		return p
+	}
+	return g.Priorities[model.SeverityCritical]
+}
+
+func (g *GotifyNotifier) send(token string, msg model.GotifyMessage) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal gotify message: %w", err)
+	}
+	status, data, err := sendWithRetry(g.Client, g.Logger, g.GetName(), func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPost, g.ServerURL+"/message", bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		setPushAuth(req, g.Auth)
+		req.Header.Set("X-Gotify-Key", token)
+		return req, nil
+	}, nil)
+	if err != nil {
+		return err
+	}
+	if status < 200 || status >= 300 {
+		return pushError(status, data)
+	}
+	return nil
+}
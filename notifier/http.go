@@ -0,0 +1,92 @@
+package notifier
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	// maxRetries bounds how often a request is resent after a 429.
+	maxRetries = 3
+	// maxRetryAfter caps the wait asked for by a rate-limited API so a
+	// flood limit cannot stall the checker for long.
+	maxRetryAfter = 30 * time.Second
+	// requestTimeout bounds one request of the HTTP notifiers.
+	requestTimeout = 15 * time.Second
+)
+
+// newHTTPClient returns the client the HTTP notifiers use by default.
+func newHTTPClient() *http.Client {
+	return &http.Client{Timeout: requestTimeout}
+}
+
+// sendToEach calls send for every recipient and joins the failures. Most
+// recipients of the HTTP notifiers are secrets (webhook URLs, routing keys,
+// app tokens), so logs and errors name them by kind and position, such as
+// "webhook[0]"; an empty kind logs the recipient itself. failed and sent
+// are the log events and attrs are added to both.
+func sendToEach(logger *slog.Logger, notifier, kind string, recipients []string, failed, sent string, attrs []any, send func(recipient string) error) error {
+	var errs []error
+	for i, recipient := range recipients {
+		target := recipient
+		if kind != "" {
+			target = fmt.Sprintf("%s[%d]", kind, i)
+		}
+		args := append([]any{"notifier", notifier, "target", target}, attrs...)
+		if err := send(recipient); err != nil {
+			logger.Error(failed, append(args, "error", err)...)
+			errs = append(errs, fmt.Errorf("%s: %w", target, err))
+			continue
+		}
+		logger.Info(sent, args...)
+	}
+	return errors.Join(errs...)
+}
+
+// sendWithRetry sends the request built by newReq and returns the response
+// status and body. On 429 it waits for the delay given by the Retry-After
+// header, or by bodyDelay when the API puts it in the body, and tries again.
+// Other statuses are left to the caller.
+func sendWithRetry(client *http.Client, logger *slog.Logger, name string, newReq func() (*http.Request, error), bodyDelay func([]byte) time.Duration) (int, []byte, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := newReq()
+		if err != nil {
+			return 0, nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			// URLs may carry tokens (bot APIs, webhooks); keep them out of
+			// errors and logs.
+			var urlErr *url.Error
+			if errors.As(err, &urlErr) {
+				err = urlErr.Err
+			}
+			return 0, nil, fmt.Errorf("request failed: %w", err)
+		}
+		data, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusTooManyRequests || attempt == maxRetries {
+			return resp.StatusCode, data, nil
+		}
+
+		wait := time.Second
+		if secs, err := strconv.ParseFloat(resp.Header.Get("Retry-After"), 64); err == nil && secs > 0 {
+			wait = time.Duration(secs * float64(time.Second))
+		} else if bodyDelay != nil {
+			if d := bodyDelay(data); d > 0 {
+				wait = d
+			}
+		}
+		if wait > maxRetryAfter {
+			return resp.StatusCode, data, fmt.Errorf("rate limited for %s", wait)
+		}
+		logger.Warn("notifier_rate_limited", "notifier", name, "retry_after", wait)
+		time.Sleep(wait)
+	}
+}
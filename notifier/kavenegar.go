@@ -0,0 +1,251 @@
+package notifier
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"healthy-api/model"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultKavenegarAPIBaseURL = "https://api.kavenegar.com"
+
+	// kavenegarMessageTemplate is the default sms/send text. SMS are paid
+	// per part, so it leaves out the trace.
+	kavenegarMessageTemplate = "{{.ServiceName}} is {{.State}}\n{{.Reason}}"
+)
+
+// kavenegarTokenSpaces is how many spaces each lookup token may hold.
+var kavenegarTokenSpaces = map[string]int{
+	"token":   0,
+	"token2":  0,
+	"token3":  0,
+	"token10": 5,
+	"token20": 8,
+}
+
+// kavenegarStatusText describes the return.status codes of failed calls;
+// the API's own messages are in Persian.
+var kavenegarStatusText = map[int]string{
+	400: "invalid or incomplete parameters",
+	401: "account is disabled",
+	402: "operation failed",
+	403: "invalid API key",
+	404: "unknown method",
+	405: "wrong HTTP method",
+	406: "required parameters are missing",
+	407: "access denied",
+	409: "server is unavailable",
+	411: "invalid receptor",
+	412: "invalid sender line",
+	413: "message is empty or too long",
+	414: "too many receptors",
+	417: "invalid date",
+	418: "insufficient credit",
+	422: "message contains invalid characters",
+	424: "lookup template not found",
+	426: "method requires an advanced plan",
+	431: "invalid token format",
+	432: "template has no %token parameter",
+}
+
+// KavenegarError is a call that Kavenegar answered with a return.status
+// other than 200.
+type KavenegarError struct {
+	Status  int
+	Message string
+}
+
+func (e *KavenegarError) Error() string {
+	text, ok := kavenegarStatusText[e.Status]
+	switch {
+	case !ok && e.Message == "":
+		return fmt.Sprintf("kavenegar status %d", e.Status)
+	case !ok:
+		return fmt.Sprintf("kavenegar status %d: %s", e.Status, e.Message)
+	case e.Message == "":
+		return fmt.Sprintf("kavenegar status %d: %s", e.Status, text)
+	}
+	return fmt.Sprintf("kavenegar status %d: %s (%s)", e.Status, text, e.Message)
+}
+
+// KavenegarNotifier sends SMS with sms/send, or with verify/lookup when a
+// lookup template is configured.
+type KavenegarNotifier struct {
+	ID             string
+	APIKey         string
+	Sender         string
+	APIBaseURL     string
+	LookupTemplate string
+	Tokens         map[string]string
+	Message        *MessageTemplate
+	Client         *http.Client
+	Logger         *slog.Logger
+}
+
+func NewKavenegarNotifier(cfg model.Kavenegar, logger *slog.Logger) (*KavenegarNotifier, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("api_key is required")
+	}
+	tokens := cfg.Tokens
+	if len(tokens) == 0 {
+		tokens = map[string]string{"token": "service_name"}
+	}
+	for param, field := range tokens {
+		if _, ok := kavenegarTokenSpaces[param]; !ok {
+			return nil, fmt.Errorf("tokens: unknown parameter '%s', use token, token2, token3, token10 or token20", param)
+		}
+		if _, err := kavenegarField(model.Notification{}, field); err != nil {
+			return nil, fmt.Errorf("tokens: %s: %w", param, err)
+		}
+	}
+	if _, ok := tokens["token"]; !ok && cfg.LookupTemplate != "" {
+		return nil, fmt.Errorf("tokens: token is required by verify/lookup")
+	}
+	text := cfg.Template
+	if text == "" {
+		text = kavenegarMessageTemplate
+	}
+	message, err := NewMessageTemplate(text, MarkupPlain)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	baseURL := cfg.APIBaseURL
+	if baseURL == "" {
+		baseURL = DefaultKavenegarAPIBaseURL
+	}
+	return &KavenegarNotifier{
+		ID:             cfg.ID,
+		APIKey:         cfg.APIKey,
+		Sender:         cfg.Sender,
+		APIBaseURL:     strings.TrimRight(baseURL, "/"),
+		LookupTemplate: cfg.LookupTemplate,
+		Tokens:         tokens,
+		Message:        message,
+		Client:         newHTTPClient(),
+		Logger:         logger,
+	}, nil
+}
+
+func (k *KavenegarNotifier) GetName() string {
+	return fmt.Sprintf("KavenegarNotifier(%s)", k.ID)
+}
+
+func (k *KavenegarNotifier) Notify(n model.Notification) error {
+	method, params, err := k.params(n)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, receptor := range n.Recipients {
+		params.Set("receptor", receptor)
+		entries, err := k.call(method, params)
+		if err != nil {
+			k.Logger.Error("sms_delivery_failed", "provider", "kavenegar", "notifier", k.ID, "target", receptor, "method", method, "error", err)
+			errs = append(errs, fmt.Errorf("receptor %s: %w", receptor, err))
+			continue
+		}
+		var messageID int64
+		if len(entries) > 0 {
+			messageID = entries[0].MessageID
+		}
+		k.Logger.Info("sms_delivery_success", "provider", "kavenegar", "notifier", k.ID, "target", receptor, "method", method, "message_id", messageID)
+	}
+	return errors.Join(errs...)
+}
+
+// params returns the API method and its parameters, without the receptor.
+func (k *KavenegarNotifier) params(n model.Notification) (string, url.Values, error) {
+	params := url.Values{}
+	if k.LookupTemplate != "" {
+		params.Set("template", k.LookupTemplate)
+		for param, field := range k.Tokens {
+			value, err := kavenegarField(n, field)
+			if err != nil {
+				return "", nil, err
+			}
+			params.Set(param, kavenegarToken(value, kavenegarTokenSpaces[param]))
+		}
+		return "verify/lookup", params, nil
+	}
+	message, err := k.Message.Render(n)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to render kavenegar message: %w", err)
+	}
+	params.Set("message", message)
+	if k.Sender != "" {
+		params.Set("sender", k.Sender)
+	}
+	return "sms/send", params, nil
+}
+
+// call posts params to the method. The API key is part of the URL, which
+// sendWithRetry keeps out of errors.
+func (k *KavenegarNotifier) call(method string, params url.Values) ([]model.KavenegarEntry, error) {
+	endpoint := fmt.Sprintf("%s/v1/%s/%s.json", k.APIBaseURL, url.PathEscape(k.APIKey), method)
+	body := params.Encode()
+	status, data, err := sendWithRetry(k.Client, k.Logger, k.GetName(), func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return req, nil
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	// The HTTP status mirrors return.status, which carries the reason.
+	var result model.KavenegarResponse
+	if err := json.Unmarshal(data, &result); err != nil || result.Return.Status == 0 {
+		return nil, fmt.Errorf("status %d: %s", status, truncate(strings.TrimSpace(string(data)), 200))
+	}
+	if result.Return.Status != http.StatusOK {
+		return nil, &KavenegarError{Status: result.Return.Status, Message: result.Return.Message}
+	}
+	return result.Entries, nil
+}
+
+// kavenegarField returns the value of a notification field for a lookup
+// token.
+func kavenegarField(n model.Notification, field string) (string, error) {
+	switch field {
+	case "service_name":
+		return n.ServiceName, nil
+	case "url":
+		return n.URL, nil
+	case "state":
+		return string(n.State), nil
+	case "severity":
+		return string(n.Severity), nil
+	case "reason":
+		return firstLine(n.Reason), nil
+	case "status_code":
+		if n.StatusCode == 0 {
+			return "", nil
+		}
+		return strconv.Itoa(n.StatusCode), nil
+	case "response_time":
+		return n.ResponseTime, nil
+	}
+	return "", fmt.Errorf("unknown field '%s', use service_name, url, state, severity, reason, status_code or response_time", field)
+}
+
+// kavenegarToken fits value into a token that may hold the given number of
+// spaces: the words past the limit are joined with underscores. Kavenegar
+// rejects empty tokens, so those become "-".
+func kavenegarToken(value string, spaces int) string {
+	words := strings.Fields(value)
+	if len(words) == 0 {
+		return "-"
+	}
+	if len(words) > spaces+1 {
+		words = append(words[:spaces], strings.Join(words[spaces:], "_"))
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,150 @@
+package notifier_test
+
+import (
+	"errors"
+	"healthy-api/model"
+	"healthy-api/notifier"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func newKavenegarServer(t *testing.T, handle func(w http.ResponseWriter, path string, form url.Values)) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("failed to parse form: %v", err)
+		}
+		handle(w, r.URL.Path, r.PostForm)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newKavenegarNotifier(t *testing.T, cfg model.Kavenegar) *notifier.KavenegarNotifier {
+	t.Helper()
+	cfg.ID = "kavenegar"
+	cfg.APIKey = "API-KEY"
+	kv, err := notifier.NewKavenegarNotifier(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return kv
+}
+
+func TestKavenegarNotifier_Send(t *testing.T) {
+	var receptors []string
+	server := newKavenegarServer(t, func(w http.ResponseWriter, path string, form url.Values) {
+		if path != "/v1/API-KEY/sms/send.json" {
+			t.Errorf("unexpected path %s", path)
+		}
+		if form.Get("sender") != "10004346" || form.Get("message") != "api is DOWN\nStatus code 503" {
+			t.Errorf("unexpected form: %v", form)
+		}
+		receptors = append(receptors, form.Get("receptor"))
+		w.Write([]byte(`{"return":{"status":200,"message":"تایید شد"},"entries":[{"messageid":8792343,"status":1,"receptor":"09123456789","cost":120}]}`))
+	})
+	kv := newKavenegarNotifier(t, model.Kavenegar{APIBaseURL: server.URL, Sender: "10004346"})
+
+	err := kv.Notify(model.Notification{
+		ServiceName: "api",
+		Recipients:  []string{"09123456789", "09351234567"},
+		Reason:      "Status code 503",
+		State:       model.StateDown,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(receptors, ",") != "09123456789,09351234567" {
+		t.Errorf("unexpected receptors: %v", receptors)
+	}
+}
+
+func TestKavenegarNotifier_LookupTokens(t *testing.T) {
+	var form url.Values
+	server := newKavenegarServer(t, func(w http.ResponseWriter, path string, f url.Values) {
+		if path != "/v1/API-KEY/verify/lookup.json" {
+			t.Errorf("unexpected path %s", path)
+		}
+		form = f
+		w.Write([]byte(`{"return":{"status":200,"message":"تایید شد"},"entries":[{"messageid":1}]}`))
+	})
+	kv := newKavenegarNotifier(t, model.Kavenegar{
+		APIBaseURL:     server.URL,
+		LookupTemplate: "service-down",
+		Tokens: map[string]string{
+			"token":   "service_name",
+			"token2":  "status_code",
+			"token10": "reason",
+		},
+	})
+
+	err := kv.Notify(model.Notification{
+		ServiceName: "user service",
+		Recipients:  []string{"09123456789"},
+		Reason:      "Response time 2.1s is above the 1s limit for three checks\nsecond line",
+		State:       model.StateDegraded,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]string{
+		"receptor": "09123456789",
+		"template": "service-down",
+		"token":    "user_service",
+		"token2":   "-",
+		"token10":  "Response time 2.1s is above the_1s_limit_for_three_checks",
+	}
+	for key, value := range want {
+		if form.Get(key) != value {
+			t.Errorf("%s = %q, want %q", key, form.Get(key), value)
+		}
+	}
+	if form.Has("message") || form.Has("sender") {
+		t.Errorf("lookup sent sms/send parameters: %v", form)
+	}
+}
+
+func TestKavenegarNotifier_ReturnStatusErrors(t *testing.T) {
+	server := newKavenegarServer(t, func(w http.ResponseWriter, path string, form url.Values) {
+		w.WriteHeader(418)
+		w.Write([]byte(`{"return":{"status":418,"message":"اعتبار حساب شما کافی نیست"},"entries":null}`))
+	})
+	kv := newKavenegarNotifier(t, model.Kavenegar{APIBaseURL: server.URL})
+
+	err := kv.Notify(model.Notification{ServiceName: "api", Recipients: []string{"09123456789"}, State: model.StateDown})
+	var kvErr *notifier.KavenegarError
+	if !errors.As(err, &kvErr) || kvErr.Status != 418 {
+		t.Fatalf("expected a KavenegarError with status 418, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "insufficient credit") {
+		t.Errorf("unexpected error text: %v", err)
+	}
+	if strings.Contains(err.Error(), "API-KEY") {
+		t.Errorf("error leaks the API key: %v", err)
+	}
+}
+
+func TestNewKavenegarNotifier_ValidatesTokens(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for name, tokens := range map[string]map[string]string{
+		"unknown parameter": {"token": "service_name", "token4": "state"},
+		"unknown field":     {"token": "hostname"},
+		"missing token":     {"token2": "state"},
+	} {
+		cfg := model.Kavenegar{ID: "kv", APIKey: "key", LookupTemplate: "down", Tokens: tokens}
+		if _, err := notifier.NewKavenegarNotifier(cfg, logger); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	// sms/send does not use tokens, so token is not required.
+	cfg := model.Kavenegar{ID: "kv", APIKey: "key", Tokens: map[string]string{"token2": "state"}}
+	if _, err := notifier.NewKavenegarNotifier(cfg, logger); err != nil {
+		t.Errorf("sms/send without token: unexpected error: %v", err)
+	}
+}
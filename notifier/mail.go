@@ -17,8 +17,9 @@ type MailNotifier struct {
 	Logger   *slog.Logger
 }
 
-func (m *MailNotifier) CreateMessage(serviceName string, to string, subject string) string {
-	return fmt.Sprintf("From: %s\nTo: %s\nSubject: %s\n\nService **%s** is not working good check it fast please.", m.Sender, to, subject, serviceName)
+func (m *MailNotifier) CreateMessage(n model.Notification, to string, subject string) string {
+	return fmt.Sprintf("From: %s\nTo: %s\nSubject: %s\n\nService **%s** is not working good check it fast please.\n\nState: %s\nReason: %s\nStatus code: %d\nResponse time: %s\nTiming: %s\n\n%s\n",
+		m.Sender, to, subject, n.ServiceName, n.State, n.Reason, n.StatusCode, n.ResponseTime, n.Timing, n.Trace)
 }
 func (m *MailNotifier) GetName() string {
 	return fmt.Sprintf("MailNotifier(%s)", m.Server)
@@ -28,7 +29,11 @@ func (m *MailNotifier) Notify(n model.Notification) error {
 	addr := fmt.Sprintf("%s:%s", m.Server, m.Port)
 	for _, mail := range n.Recipients {
 		go func(target string) {
-			msg := m.CreateMessage(n.ServiceName, target, "Alert")
+			subject := "Alert"
+			if n.State != "" {
+				subject += ": " + string(n.State)
+			}
+			msg := m.CreateMessage(n, target, subject)
 			err := smtp.SendMail(addr, auth, m.Sender, []string{mail}, bytes.NewBufferString(msg).Bytes())
 			if err != nil {
 				m.Logger.Error("email_send_failed", "target", target, "addr", addr)				// return fmt.Errorf("error while sending mail to %s:%w", target, err)
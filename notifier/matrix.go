@@ -0,0 +1,184 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"healthy-api/model"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// matrixTemplate is the default formatted_body. Clients do not turn line
+// breaks of HTML bodies into new lines, so it uses <br>.
+const matrixTemplate = "{{.Icon}} <b>{{.ServiceName}}</b> is <b>{{.State}}</b><br>" +
+	"Reason: {{.Reason}}<br>" +
+	"{{if .StatusCode}}Status code: {{.StatusCode}}<br>{{end}}" +
+	"Response time: {{.ResponseTime}}" +
+	"{{if .Trace}}<pre><code>{{.Trace}}</code></pre>{{end}}"
+
+// matrixRetryDelay is the wait before resending an event after a network
+// error or a 5xx. The event keeps its transaction ID, so a request that did
+// reach the homeserver is not posted twice.
+const matrixRetryDelay = time.Second
+
+// MatrixNotifier sends m.room.message events to rooms. Recipients are room
+// IDs.
+type MatrixNotifier struct {
+	ID            string
+	HomeserverURL string
+	AccessToken   string
+	MsgType       string
+	Body          *MessageTemplate
+	FormattedBody *MessageTemplate
+	Client        *http.Client
+	Logger        *slog.Logger
+
+	txnPrefix string
+	txnSeq    atomic.Uint64
+}
+
+func NewMatrixNotifier(cfg model.Matrix, logger *slog.Logger) (*MatrixNotifier, error) {
+	if cfg.HomeserverURL == "" {
+		return nil, fmt.Errorf("homeserver_url is required")
+	}
+	if cfg.AccessToken == "" {
+		return nil, fmt.Errorf("access_token is required")
+	}
+	msgType := cfg.MsgType
+	switch msgType {
+	case "":
+		msgType = "m.text"
+	case "m.text", "m.notice":
+	default:
+		return nil, fmt.Errorf("unknown msgtype '%s', use m.text or m.notice", cfg.MsgType)
+	}
+	body, err := NewMessageTemplate("", MarkupPlain)
+	if err != nil {
+		return nil, err
+	}
+	text := cfg.Template
+	if text == "" {
+		text = matrixTemplate
+	}
+	formatted, err := NewMessageTemplate(text, MarkupHTML)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return &MatrixNotifier{
+		ID:            cfg.ID,
+		HomeserverURL: strings.TrimRight(cfg.HomeserverURL, "/"),
+		AccessToken:   cfg.AccessToken,
+		MsgType:       msgType,
+		Body:          body,
+		FormattedBody: formatted,
+		Client:        newHTTPClient(),
+		Logger:        logger,
+		// Transaction IDs only need to be unique per access token; the
+		// start time keeps them apart across restarts.
+		txnPrefix: "healthy-api." + strconv.FormatInt(time.Now().UnixNano(), 36),
+	}, nil
+}
+
+func (m *MatrixNotifier) GetName() string {
+	return fmt.Sprintf("MatrixNotifier(%s)", m.ID)
+}
+
+// Resolve posts the recovery to the rooms.
+func (m *MatrixNotifier) Resolve(n model.Notification) error {
+	return m.Notify(n)
+}
+
+func (m *MatrixNotifier) Notify(n model.Notification) error {
+	body, err := m.Body.Render(n)
+	if err != nil {
+		return fmt.Errorf("failed to render matrix body: %w", err)
+	}
+	formatted, err := m.FormattedBody.Render(n)
+	if err != nil {
+		return fmt.Errorf("failed to render matrix formatted_body: %w", err)
+	}
+	msg := model.MatrixMessage{
+		MsgType:       m.MsgType,
+		Body:          body,
+		Format:        "org.matrix.custom.html",
+		FormattedBody: formatted,
+	}
+	var errs []error
+	for _, roomID := range n.Recipients {
+		eventID, err := m.send(roomID, msg)
+		if err != nil {
+			m.Logger.Error("matrix_send_failed", "notifier", m.ID, "room_id", roomID, "error", err)
+			errs = append(errs, fmt.Errorf("room %s: %w", roomID, err))
+			continue
+		}
+		m.Logger.Info("matrix_sent", "notifier", m.ID, "room_id", roomID, "service", n.ServiceName, "event_id", eventID)
+	}
+	return errors.Join(errs...)
+}
+
+// send PUTs the event under a new transaction ID and returns its event ID.
+func (m *MatrixNotifier) send(roomID string, msg model.MatrixMessage) (string, error) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal matrix message: %w", err)
+	}
+	txnID := fmt.Sprintf("%s.%d", m.txnPrefix, m.txnSeq.Add(1))
+	endpoint := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s",
+		m.HomeserverURL, url.PathEscape(roomID), url.PathEscape(txnID))
+	newReq := func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPut, endpoint, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+m.AccessToken)
+		return req, nil
+	}
+
+	var (
+		status int
+		data   []byte
+	)
+	for attempt := 0; ; attempt++ {
+		status, data, err = sendWithRetry(m.Client, m.Logger, m.GetName(), newReq, matrixRetryAfter)
+		if (err == nil && status < 500) || attempt == maxRetries {
+			break
+		}
+		m.Logger.Warn("matrix_send_retry", "notifier", m.ID, "room_id", roomID, "txn_id", txnID, "status", status, "error", err)
+		time.Sleep(matrixRetryDelay)
+	}
+	if err != nil {
+		return "", err
+	}
+	if status >= 200 && status < 300 {
+		var result model.MatrixSendResponse
+		if err := json.Unmarshal(data, &result); err != nil {
+			return "", fmt.Errorf("status %d: failed to decode response: %w", status, err)
+		}
+		if result.EventID == "" {
+			return "", fmt.Errorf("status %d: response has no event_id", status)
+		}
+		return result.EventID, nil
+	}
+	var result model.MatrixError
+	if json.Unmarshal(data, &result) == nil && result.ErrCode != "" {
+		return "", fmt.Errorf("status %d: %s: %s", status, result.ErrCode, result.Error)
+	}
+	return "", fmt.Errorf("status %d: %s", status, truncate(strings.TrimSpace(string(data)), 200))
+}
+
+// matrixRetryAfter reads retry_after_ms from an M_LIMIT_EXCEEDED error.
+func matrixRetryAfter(body []byte) time.Duration {
+	var result model.MatrixError
+	if json.Unmarshal(body, &result) != nil {
+		return 0
+	}
+	return time.Duration(result.RetryAfterMs) * time.Millisecond
+}
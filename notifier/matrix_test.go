@@ -0,0 +1,140 @@
+package notifier_test
+
+import (
+	"encoding/json"
+	"healthy-api/model"
+	"healthy-api/notifier"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newMatrixNotifier(t *testing.T, cfg model.Matrix) *notifier.MatrixNotifier {
+	t.Helper()
+	cfg.ID = "matrix"
+	cfg.AccessToken = "syt_secret"
+	mx, err := notifier.NewMatrixNotifier(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return mx
+}
+
+func TestMatrixNotifier_SendsHTMLMessages(t *testing.T) {
+	const prefix = "/_matrix/client/v3/rooms/!ops:example.org/send/m.room.message/"
+	var (
+		txnIDs   []string
+		received model.MatrixMessage
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || !strings.HasPrefix(r.URL.Path, prefix) {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer syt_secret" {
+			t.Errorf("unexpected authorization %q", r.Header.Get("Authorization"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("failed to decode request body: %v", err)
+		}
+		txnIDs = append(txnIDs, strings.TrimPrefix(r.URL.Path, prefix))
+		w.Write([]byte(`{"event_id":"$event"}`))
+	}))
+	defer server.Close()
+	mx := newMatrixNotifier(t, model.Matrix{HomeserverURL: server.URL + "/", MsgType: "m.notice"})
+
+	n := model.Notification{
+		ServiceName:  "api<v2>",
+		Recipients:   []string{"!ops:example.org"},
+		Reason:       "Status code 503",
+		StatusCode:   503,
+		ResponseTime: "1.2s",
+		State:        model.StateDown,
+	}
+	if err := mx.Notify(n); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := mx.Resolve(n); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(txnIDs) != 2 || txnIDs[0] == "" || txnIDs[0] == txnIDs[1] {
+		t.Errorf("expected two distinct transaction IDs, got %v", txnIDs)
+	}
+	if received.MsgType != "m.notice" || received.Format != "org.matrix.custom.html" {
+		t.Errorf("unexpected message: %+v", received)
+	}
+	if !strings.HasPrefix(received.Body, "🔴 api<v2> is DOWN\nReason: Status code 503") {
+		t.Errorf("unexpected body %q", received.Body)
+	}
+	if !strings.Contains(received.FormattedBody, "<b>api&lt;v2&gt;</b> is <b>DOWN</b><br>Reason: Status code 503<br>Status code: 503<br>") {
+		t.Errorf("unexpected formatted_body %q", received.FormattedBody)
+	}
+}
+
+func TestMatrixNotifier_RetriesWithTheSameTransactionID(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if len(paths) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"event_id":"$event"}`))
+	}))
+	defer server.Close()
+	mx := newMatrixNotifier(t, model.Matrix{HomeserverURL: server.URL})
+
+	err := mx.Notify(model.Notification{ServiceName: "api", Recipients: []string{"!ops:example.org"}, State: model.StateDown})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(paths) != 2 || paths[0] != paths[1] {
+		t.Errorf("expected the retry to reuse the transaction ID, got %v", paths)
+	}
+}
+
+func TestMatrixNotifier_ReportsErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"errcode":"M_FORBIDDEN","error":"User is not in the room"}`))
+	}))
+	defer server.Close()
+	mx := newMatrixNotifier(t, model.Matrix{HomeserverURL: server.URL})
+
+	err := mx.Notify(model.Notification{ServiceName: "api", Recipients: []string{"!ops:example.org"}, State: model.StateDown})
+	if err == nil || !strings.Contains(err.Error(), "status 403: M_FORBIDDEN: User is not in the room") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestMatrixNotifier_RejectsResponsesWithoutEventID(t *testing.T) {
+	for name, body := range map[string]string{
+		"malformed":   `<html>ok</html>`,
+		"no event_id": `{}`,
+	} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body))
+		}))
+		mx := newMatrixNotifier(t, model.Matrix{HomeserverURL: server.URL})
+		err := mx.Notify(model.Notification{ServiceName: "api", Recipients: []string{"!ops:example.org"}, State: model.StateDown})
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+		server.Close()
+	}
+}
+
+func TestNewMatrixNotifier_Validates(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for name, cfg := range map[string]model.Matrix{
+		"no homeserver":   {AccessToken: "t"},
+		"no access token": {HomeserverURL: "https://matrix.example.org"},
+		"unknown msgtype": {HomeserverURL: "https://matrix.example.org", AccessToken: "t", MsgType: "m.emote"},
+	} {
+		if _, err := notifier.NewMatrixNotifier(cfg, logger); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
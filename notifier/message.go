@@ -0,0 +1,206 @@
+package notifier
+
+import (
+	"bytes"
+	"fmt"
+	"healthy-api/model"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Markup is the formatting language of a chat message.
+type Markup string
+
+const (
+	MarkupPlain      Markup = ""
+	MarkupMarkdownV2 Markup = "MarkdownV2"
+	MarkupHTML       Markup = "HTML"
+	// MarkupSlack is Slack's mrkdwn.
+	MarkupSlack Markup = "mrkdwn"
+	// MarkupRTL is plain text for right-to-left (Persian) templates, as
+	// used by Bale.
+	MarkupRTL Markup = "rtl"
+)
+
+func (m Markup) Validate() error {
+	switch m {
+	case MarkupPlain, MarkupMarkdownV2, MarkupHTML, MarkupSlack, MarkupRTL:
+		return nil
+	}
+	return fmt.Errorf("unknown markup '%s'", m)
+}
+
+var (
+	markdownV2Replacer = strings.NewReplacer(
+		`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`,
+		"~", `\~`, "`", "\\`", ">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`,
+		"|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+	)
+	htmlReplacer = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+)
+
+// Escape makes s safe to embed as literal text in the markup.
+func (m Markup) Escape(s string) string {
+	switch m {
+	case MarkupMarkdownV2:
+		return markdownV2Replacer.Replace(s)
+	case MarkupHTML, MarkupSlack:
+		return htmlReplacer.Replace(s)
+	case MarkupRTL:
+		return isolateLines(s)
+	}
+	return s
+}
+
+// isolateLines wraps every non-empty line of s in First Strong Isolate and
+// Pop Directional Isolate marks, so Latin service names, URLs and numbers
+// keep their own direction without reordering the Persian text around
+// them. Isolates end at line breaks, hence one pair per line.
+func isolateLines(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = "\u2068" + line + "\u2069"
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// DefaultTemplate is used when a notifier has no template of its own.
+func (m Markup) DefaultTemplate() string {
+	switch m {
+	case MarkupMarkdownV2:
+		return "{{.Icon}} *{{.ServiceName}}* is *{{.State}}*\n" +
+			"Reason: {{.Reason}}\n" +
+			"{{if .StatusCode}}Status code: {{.StatusCode}}\n{{end}}" +
+			"Response time: {{.ResponseTime}}" +
+			"{{if .Trace}}\n```\n{{.Trace}}\n```{{end}}"
+	case MarkupHTML:
+		return "{{.Icon}} <b>{{.ServiceName}}</b> is <b>{{.State}}</b>\n" +
+			"Reason: {{.Reason}}\n" +
+			"{{if .StatusCode}}Status code: {{.StatusCode}}\n{{end}}" +
+			"Response time: {{.ResponseTime}}" +
+			"{{if .Trace}}\n<pre>{{.Trace}}</pre>{{end}}"
+	case MarkupSlack:
+		// Slack messages carry the details in attachment fields.
+		return "{{.Icon}} *{{.ServiceName}}* is *{{.State}}*"
+	case MarkupRTL:
+		return "{{.Icon}} سرویس {{.ServiceName}} در وضعیت {{.State}} است\n" +
+			"علت: {{.Reason}}\n" +
+			"{{if .StatusCode}}کد وضعیت: {{.StatusCode}}\n{{end}}" +
+			"زمان پاسخ: {{.ResponseTime}}" +
+			"{{if .Trace}}\n\n{{.Trace}}{{end}}"
+	}
+	return "{{.Icon}} {{.ServiceName}} is {{.State}}\n" +
+		"Reason: {{.Reason}}\n" +
+		"{{if .StatusCode}}Status code: {{.StatusCode}}\n{{end}}" +
+		"Response time: {{.ResponseTime}}" +
+		"{{if .Trace}}\n\n{{.Trace}}{{end}}"
+}
+
+// HeadlineTemplate is the default one-line title of notifiers that show the
+// details as fields (Discord, Teams).
+const HeadlineTemplate = "{{.Icon}} {{.ServiceName}} is {{.State}}"
+
+// MessageData is what message templates see. Strings are already escaped
+// for the markup, so templates use them as they are.
+type MessageData struct {
+	ServiceName  string
+	URL          string
+	State        string
+	Severity     string
+	Icon         string // 🔴 DOWN, 🟠 DEGRADED, 🟢 UP
+	Reason       string
+	StatusCode   int
+	ResponseTime string
+	Timing       string
+	Trace        string
+	TimeStamp    string
+}
+
+// Long reasons (content_drift diffs) and traces are cut so the message stays
+// below the size limits of chat services.
+const (
+	maxMessageReason = 1500
+	maxMessageTrace  = 1500
+)
+
+// MessageTemplate renders notifications as chat messages.
+type MessageTemplate struct {
+	markup Markup
+	tmpl   *template.Template
+}
+
+// NewMessageTemplate parses text, or the markup's default template when text
+// is empty.
+func NewMessageTemplate(text string, markup Markup) (*MessageTemplate, error) {
+	if err := markup.Validate(); err != nil {
+		return nil, err
+	}
+	if text == "" {
+		text = markup.DefaultTemplate()
+	}
+	tmpl, err := template.New("message").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	t := &MessageTemplate{markup: markup, tmpl: tmpl}
+	// Catch references to unknown fields at startup instead of on the first
+	// alert.
+	if _, err := t.Render(model.Notification{ServiceName: "test", State: model.StateDown}); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *MessageTemplate) Render(n model.Notification) (string, error) {
+	esc := t.markup.Escape
+	data := MessageData{
+		ServiceName:  esc(n.ServiceName),
+		URL:          esc(n.URL),
+		State:        esc(string(n.State)),
+		Severity:     esc(string(n.Severity)),
+		Icon:         stateIcon(n.State),
+		Reason:       esc(truncate(n.Reason, maxMessageReason)),
+		StatusCode:   n.StatusCode,
+		ResponseTime: esc(n.ResponseTime),
+		Trace:        esc(truncate(n.Trace.String(), maxMessageTrace)),
+		TimeStamp:    esc(time.Now().Format(time.RFC3339)),
+	}
+	if n.Timing != (model.Timing{}) {
+		data.Timing = esc(n.Timing.String())
+	}
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func stateIcon(s model.ServiceState) string {
+	switch s {
+	case model.StateUp:
+		return "🟢"
+	case model.StateDegraded:
+		return "🟠"
+	}
+	return "🔴"
+}
+
+// truncate cuts s to at most n runes, marking the cut with an ellipsis.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+// firstLine returns the first line of s; reasons can hold multiline diffs.
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
@@ -0,0 +1,140 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"healthy-api/model"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Teams rejects messages over 28 KB. Reasons and traces are already cut by
+// the message limits; a card still too large is sent without its trace.
+const teamsMaxPayload = 28 * 1024
+
+var teamsColors = map[model.ServiceState]string{
+	model.StateUp:       "Good",
+	model.StateDegraded: "Warning",
+	model.StateDown:     "Attention",
+}
+
+// TeamsNotifier posts Adaptive Cards to Microsoft Teams. Recipients are
+// incoming webhook or Workflows URLs.
+type TeamsNotifier struct {
+	ID     string
+	Title  *MessageTemplate
+	Client *http.Client
+	Logger *slog.Logger
+}
+
+func NewTeamsNotifier(cfg model.MSTeams, logger *slog.Logger) (*TeamsNotifier, error) {
+	title := cfg.Title
+	if title == "" {
+		title = HeadlineTemplate
+	}
+	tmpl, err := NewMessageTemplate(title, MarkupPlain)
+	if err != nil {
+		return nil, fmt.Errorf("invalid title: %w", err)
+	}
+	return &TeamsNotifier{
+		ID:     cfg.ID,
+		Title:  tmpl,
+		Client: newHTTPClient(),
+		Logger: logger,
+	}, nil
+}
+
+func (t *TeamsNotifier) GetName() string {
+	return fmt.Sprintf("TeamsNotifier(%s)", t.ID)
+}
+
+// Resolve posts the recovery as a card with a green title.
+func (t *TeamsNotifier) Resolve(n model.Notification) error {
+	return t.Notify(n)
+}
+
+func (t *TeamsNotifier) Notify(n model.Notification) error {
+	body, err := t.payload(n)
+	if err != nil {
+		return err
+	}
+	attrs := []any{"service", n.ServiceName, "state", n.State}
+	return sendToEach(t.Logger, t.ID, "webhook", n.Recipients, "msteams_send_failed", "msteams_sent", attrs, func(hookURL string) error {
+		return t.send(hookURL, body)
+	})
+}
+
+func (t *TeamsNotifier) payload(n model.Notification) ([]byte, error) {
+	title, err := t.Title.Render(n)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render teams title: %w", err)
+	}
+	color, ok := teamsColors[n.State]
+	if !ok {
+		color = teamsColors[model.StateDown]
+	}
+	var facts []model.AdaptiveCardFact
+	if n.StatusCode != 0 {
+		facts = append(facts, model.AdaptiveCardFact{Title: "Status code", Value: strconv.Itoa(n.StatusCode)})
+	}
+	if n.ResponseTime != "" {
+		facts = append(facts, model.AdaptiveCardFact{Title: "Response time", Value: n.ResponseTime})
+	}
+	if n.Severity != "" {
+		facts = append(facts, model.AdaptiveCardFact{Title: "Severity", Value: string(n.Severity)})
+	}
+	blocks := []model.AdaptiveCardBlock{
+		{Type: "TextBlock", Text: title, Weight: "Bolder", Size: "Medium", Color: color, Wrap: true},
+		{Type: "TextBlock", Text: truncate(n.Reason, maxMessageReason), Wrap: true},
+	}
+	if len(facts) > 0 {
+		blocks = append(blocks, model.AdaptiveCardBlock{Type: "FactSet", Facts: facts})
+	}
+	card := func(blocks []model.AdaptiveCardBlock) ([]byte, error) {
+		return json.Marshal(model.TeamsMessage{
+			Type: "message",
+			Attachments: []model.TeamsAttachment{{
+				ContentType: "application/vnd.microsoft.card.adaptive",
+				Content: model.AdaptiveCard{
+					Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+					Type:    "AdaptiveCard",
+					Version: "1.4",
+					Body:    blocks,
+					MSTeams: map[string]string{"width": "Full"},
+				},
+			}},
+		})
+	}
+	if trace := n.Trace.String(); trace != "" {
+		withTrace := append(blocks, model.AdaptiveCardBlock{
+			Type: "TextBlock", Text: truncate(trace, maxMessageTrace), FontType: "Monospace", IsSubtle: true, Wrap: true,
+		})
+		if body, err := card(withTrace); err != nil || len(body) <= teamsMaxPayload {
+			return body, err
+		}
+	}
+	return card(blocks)
+}
+
+func (t *TeamsNotifier) send(hookURL string, body []byte) error {
+	status, data, err := sendWithRetry(t.Client, t.Logger, t.GetName(), func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPost, hookURL, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	}, nil)
+	if err != nil {
+		return err
+	}
+	text := strings.TrimSpace(string(data))
+	// Legacy incoming webhooks answer 200 even when delivery fails.
+	if status < 200 || status >= 300 || strings.Contains(text, "delivery failed") {
+		return fmt.Errorf("status %d: %s", status, truncate(text, 200))
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package notifier_test
+
+import (
+	"encoding/json"
+	"healthy-api/model"
+	"healthy-api/notifier"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTeamsNotifier_AdaptiveCard(t *testing.T) {
+	var received model.TeamsMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("failed to decode request body: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	tm, err := notifier.NewTeamsNotifier(model.MSTeams{ID: "teams", Title: "{{.ServiceName}}: {{.State}}"}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = tm.Resolve(model.Notification{
+		ServiceName:  "api",
+		Recipients:   []string{server.URL},
+		Reason:       "Service is healthy again after 5m0s",
+		StatusCode:   200,
+		ResponseTime: "40ms",
+		State:        model.StateUp,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(received.Attachments) != 1 || received.Attachments[0].ContentType != "application/vnd.microsoft.card.adaptive" {
+		t.Fatalf("unexpected message: %+v", received)
+	}
+	card := received.Attachments[0].Content
+	if card.Type != "AdaptiveCard" || len(card.Body) != 3 {
+		t.Fatalf("unexpected card: %+v", card)
+	}
+	if card.Body[0].Text != "api: UP" || card.Body[0].Color != "Good" {
+		t.Errorf("unexpected title block: %+v", card.Body[0])
+	}
+	if facts := card.Body[2].Facts; len(facts) != 2 || facts[0].Value != "200" || facts[1].Value != "40ms" {
+		t.Errorf("unexpected facts: %+v", facts)
+	}
+}
+
+func TestTeamsNotifier_DeliveryFailed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("Webhook message delivery failed with error: Microsoft Teams endpoint returned HTTP error 413"))
+	}))
+	defer server.Close()
+	tm, err := notifier.NewTeamsNotifier(model.MSTeams{ID: "teams"}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = tm.Notify(model.Notification{ServiceName: "api", Recipients: []string{server.URL}, State: model.StateDown})
+	if err == nil || !strings.Contains(err.Error(), "413") {
+		t.Fatalf("expected delivery error, got %v", err)
+	}
+}
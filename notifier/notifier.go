@@ -6,3 +6,17 @@ type Notifier interface {
 	Notify(n model.Notification) error
 	GetName() string
 }
+
+// Resolver is implemented by notifiers that report recoveries. Once a
+// service is healthy again after an alert, the checker calls Resolve with
+// State UP for every target that received the alert.
+type Resolver interface {
+	Resolve(n model.Notification) error
+}
+
+// RecipientValidator is implemented by notifiers whose recipients have a
+// format of their own. It is called for every target when the config is
+// loaded, so a typo is reported at startup rather than during an incident.
+type RecipientValidator interface {
+	ValidateRecipients(recipients []string) error
+}
@@ -0,0 +1,162 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"healthy-api/model"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+const ntfyDefaultPriority = 3
+
+var ntfyStateTags = map[model.ServiceState]string{
+	model.StateUp:       "white_check_mark",
+	model.StateDegraded: "warning",
+	model.StateDown:     "rotating_light",
+}
+
+// NtfyNotifier publishes to topics of an ntfy server.
+type NtfyNotifier struct {
+	ID         string
+	ServerURL  string
+	Topic      string
+	Auth       model.PushAuth
+	Priorities map[model.Severity]int
+	Tags       []string
+	ClickURL   string
+	Title      *MessageTemplate
+	Message    *MessageTemplate
+	Client     *http.Client
+	Logger     *slog.Logger
+}
+
+func NewNtfyNotifier(cfg model.Ntfy, logger *slog.Logger) (*NtfyNotifier, error) {
+	if cfg.ServerURL == "" {
+		return nil, fmt.Errorf("server_url is required")
+	}
+	priorities, err := pushPriorities(map[model.Severity]int{
+		model.SeverityCritical: 5,
+		model.SeverityWarning:  ntfyDefaultPriority,
+	}, cfg.Priorities, 1, 5)
+	if err != nil {
+		return nil, err
+	}
+	title, err := NewMessageTemplate("{{.ServiceName}} is {{.State}}", MarkupPlain)
+	if err != nil {
+		return nil, err
+	}
+	text := cfg.Template
+	if text == "" {
+		text = pushMessageTemplate
+	}
+	message, err := NewMessageTemplate(text, MarkupPlain)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return &NtfyNotifier{
+		ID:         cfg.ID,
+		ServerURL:  strings.TrimRight(cfg.ServerURL, "/"),
+		Topic:      cfg.Topic,
+		Auth:       cfg.PushAuth,
+		Priorities: priorities,
+		Tags:       cfg.Tags,
+		ClickURL:   cfg.ClickURL,
+		Title:      title,
+		Message:    message,
+		Client:     newHTTPClient(),
+		Logger:     logger,
+	}, nil
+}
+
+func (nt *NtfyNotifier) GetName() string {
+	return fmt.Sprintf("NtfyNotifier(%s)", nt.ID)
+}
+
+// Resolve publishes the recovery at the default priority.
+func (nt *NtfyNotifier) Resolve(n model.Notification) error {
+	return nt.Notify(n)
+}
+
+func (nt *NtfyNotifier) Notify(n model.Notification) error {
+	title, err := nt.Title.Render(n)
+	if err != nil {
+		return err
+	}
+	message, err := nt.Message.Render(n)
+	if err != nil {
+		return fmt.Errorf("failed to render ntfy message: %w", err)
+	}
+	priority := ntfyDefaultPriority
+	if n.State != model.StateUp {
+		priority = nt.priority(n.Severity)
+	}
+	tags := append([]string{ntfyStateTags[n.State]}, nt.Tags...)
+	click := nt.ClickURL
+	if click == "" {
+		click = n.URL
+	}
+
+	topics := n.Recipients
+	if len(topics) == 0 && nt.Topic != "" {
+		topics = []string{nt.Topic}
+	}
+	if len(topics) == 0 {
+		err := fmt.Errorf("no topic: the target has no recipients and topic is not set")
+		nt.Logger.Error("ntfy_publish_failed", "notifier", nt.ID, "service", n.ServiceName, "error", err)
+		return err
+	}
+	var errs []error
+	for _, topic := range topics {
+		msg := model.NtfyMessage{
+			Topic:    topic,
+			Title:    title,
+			Message:  message,
+			Priority: priority,
+			Tags:     tags,
+			Click:    click,
+		}
+		if err := nt.publish(msg); err != nil {
+			nt.Logger.Error("ntfy_publish_failed", "notifier", nt.ID, "topic", topic, "error", err)
+			errs = append(errs, fmt.Errorf("topic %s: %w", topic, err))
+			continue
+		}
+		nt.Logger.Info("ntfy_published", "notifier", nt.ID, "topic", topic, "service", n.ServiceName, "priority", priority)
+	}
+	return errors.Join(errs...)
+}
+
+func (nt *NtfyNotifier) priority(s model.Severity) int {
+	if p, ok := nt.Priorities[s]; ok {
+		return p
+	}
+	return nt.Priorities[model.SeverityCritical]
+}
+
+// publish posts the message as JSON to the server root, which takes the
+// topic from the body.
+func (nt *NtfyNotifier) publish(msg model.NtfyMessage) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal ntfy message: %w", err)
+	}
+	status, data, err := sendWithRetry(nt.Client, nt.Logger, nt.GetName(), func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPost, nt.ServerURL, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		setPushAuth(req, nt.Auth)
+		return req, nil
+	}, nil)
+	if err != nil {
+		return err
+	}
+	if status < 200 || status >= 300 {
+		return pushError(status, data)
+	}
+	return nil
+}
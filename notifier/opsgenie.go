@@ -0,0 +1,240 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"healthy-api/model"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	DefaultOpsgenieAPIBaseURL = "https://api.opsgenie.com"
+
+	// Opsgenie field limits, in characters.
+	opsgenieMaxMessage     = 130
+	opsgenieMaxDescription = 15000
+	opsgenieMaxNote        = 25000
+)
+
+// OpsgenieNotifier manages one Opsgenie alert per service, using the
+// service name as the alias.
+type OpsgenieNotifier struct {
+	ID         string
+	APIKey     string
+	APIBaseURL string
+	Responders []model.OpsgenieResponder
+	Tags       []string
+	Priorities map[model.Severity]string
+	Client     *http.Client
+	Logger     *slog.Logger
+
+	mu   sync.Mutex
+	keys map[string]string // alias -> failure key of the open alert
+}
+
+func NewOpsgenieNotifier(cfg model.Opsgenie, logger *slog.Logger) (*OpsgenieNotifier, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("api_key is required")
+	}
+	responders, err := parseOpsgenieResponders(cfg.Responders)
+	if err != nil {
+		return nil, err
+	}
+	priorities := map[model.Severity]string{
+		model.SeverityCritical: "P1",
+		model.SeverityWarning:  "P3",
+	}
+	for severity, priority := range cfg.Priorities {
+		if err := severity.Validate(); err != nil || severity == "" {
+			return nil, fmt.Errorf("priorities: unknown severity '%s'", severity)
+		}
+		switch priority {
+		case "P1", "P2", "P3", "P4", "P5":
+		default:
+			return nil, fmt.Errorf("priorities: unknown priority '%s', use P1 to P5", priority)
+		}
+		priorities[severity] = priority
+	}
+	baseURL := cfg.APIBaseURL
+	if baseURL == "" {
+		baseURL = DefaultOpsgenieAPIBaseURL
+	}
+	return &OpsgenieNotifier{
+		ID:         cfg.ID,
+		APIKey:     cfg.APIKey,
+		APIBaseURL: strings.TrimRight(baseURL, "/"),
+		Responders: responders,
+		Tags:       cfg.Tags,
+		Priorities: priorities,
+		Client:     newHTTPClient(),
+		Logger:     logger,
+		keys:       make(map[string]string),
+	}, nil
+}
+
+// parseOpsgenieResponders parses "team:<name>", "user:<username>",
+// "escalation:<name>" and "schedule:<name>".
+func parseOpsgenieResponders(specs []string) ([]model.OpsgenieResponder, error) {
+	var responders []model.OpsgenieResponder
+	for _, spec := range specs {
+		kind, name, ok := strings.Cut(spec, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid responder '%s', use team:<name>, user:<username>, escalation:<name> or schedule:<name>", spec)
+		}
+		switch kind = strings.TrimSpace(kind); kind {
+		case "user":
+			responders = append(responders, model.OpsgenieResponder{Type: kind, Username: name})
+		case "team", "escalation", "schedule":
+			responders = append(responders, model.OpsgenieResponder{Type: kind, Name: name})
+		default:
+			return nil, fmt.Errorf("invalid responder type '%s' in '%s'", kind, spec)
+		}
+	}
+	return responders, nil
+}
+
+// ValidateRecipients checks that every recipient is a responder spec.
+func (o *OpsgenieNotifier) ValidateRecipients(recipients []string) error {
+	_, err := parseOpsgenieResponders(recipients)
+	return err
+}
+
+func (o *OpsgenieNotifier) GetName() string {
+	return fmt.Sprintf("OpsgenieNotifier(%s)", o.ID)
+}
+
+// Notify creates the service's alert; Opsgenie de-duplicates it by alias
+// while it is open. A note is added when the failing conditions, the state
+// or the severity differ from the previous alert.
+func (o *OpsgenieNotifier) Notify(n model.Notification) error {
+	extra, err := parseOpsgenieResponders(n.Recipients)
+	if err != nil {
+		return err
+	}
+	alias := n.ServiceName
+	priority := o.Priorities[n.Severity]
+	if priority == "" {
+		priority = o.Priorities[model.SeverityCritical]
+	}
+	details := map[string]string{
+		"state":         string(n.State),
+		"status_code":   strconv.Itoa(n.StatusCode),
+		"response_time": n.ResponseTime,
+	}
+	if n.Severity != "" {
+		details["severity"] = string(n.Severity)
+	}
+	if n.Timing != (model.Timing{}) {
+		details["timing"] = n.Timing.String()
+	}
+	description := n.Reason
+	if trace := n.Trace.String(); trace != "" {
+		description += "\n\n" + trace
+	}
+	alert := model.OpsgenieAlert{
+		Message:     truncate(fmt.Sprintf("%s is %s: %s", n.ServiceName, n.State, firstLine(n.Reason)), opsgenieMaxMessage),
+		Alias:       alias,
+		Description: truncate(description, opsgenieMaxDescription),
+		Responders:  append(append([]model.OpsgenieResponder(nil), o.Responders...), extra...),
+		Tags:        o.Tags,
+		Details:     details,
+		Entity:      n.ServiceName,
+		Source:      "Healthy-API",
+		Priority:    priority,
+	}
+	if err := o.request("/v2/alerts", alert); err != nil {
+		o.Logger.Error("opsgenie_alert_failed", "notifier", o.ID, "alias", alias, "error", err)
+		return fmt.Errorf("create alert: %w", err)
+	}
+	o.Logger.Info("opsgenie_alert_created", "notifier", o.ID, "alias", alias, "priority", priority)
+
+	o.mu.Lock()
+	key := failureKey(n)
+	previous, open := o.keys[alias]
+	o.keys[alias] = key
+	o.mu.Unlock()
+	if open && previous != key {
+		note := model.OpsgenieNote{Source: "Healthy-API", Note: truncate("Reason changed: "+n.Reason, opsgenieMaxNote)}
+		if err := o.request(o.alertPath(alias, "notes"), note); err != nil {
+			o.Logger.Error("opsgenie_note_failed", "notifier", o.ID, "alias", alias, "error", err)
+			return fmt.Errorf("add note: %w", err)
+		}
+	}
+	return nil
+}
+
+// Resolve closes the service's alert.
+func (o *OpsgenieNotifier) Resolve(n model.Notification) error {
+	alias := n.ServiceName
+	note := model.OpsgenieNote{Source: "Healthy-API", Note: n.Reason}
+	if err := o.request(o.alertPath(alias, "close"), note); err != nil {
+		o.Logger.Error("opsgenie_close_failed", "notifier", o.ID, "alias", alias, "error", err)
+		return fmt.Errorf("close alert: %w", err)
+	}
+	o.mu.Lock()
+	delete(o.keys, alias)
+	o.mu.Unlock()
+	o.Logger.Info("opsgenie_alert_closed", "notifier", o.ID, "alias", alias)
+	return nil
+}
+
+// failureKey identifies what is failing without the measured values a
+// reason carries, such as latencies or diffs, so a note is only posted when
+// a different condition fails or the state or severity changes.
+func failureKey(n model.Notification) string {
+	var paths []string
+	var walk func(t *model.TraceNode)
+	walk = func(t *model.TraceNode) {
+		if t == nil || t.Passed || t.Skipped {
+			return
+		}
+		if len(t.Children) == 0 {
+			paths = append(paths, t.Path)
+		}
+		for _, c := range t.Children {
+			walk(c)
+		}
+	}
+	walk(n.Trace)
+	return fmt.Sprintf("%s|%s|%s", n.State, n.Severity, strings.Join(paths, ","))
+}
+
+func (o *OpsgenieNotifier) alertPath(alias, action string) string {
+	return "/v2/alerts/" + url.PathEscape(alias) + "/" + action + "?identifierType=alias"
+}
+
+// request posts v to the API path. Opsgenie processes requests
+// asynchronously and answers 202 once they are accepted.
+func (o *OpsgenieNotifier) request(path string, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	status, data, err := sendWithRetry(o.Client, o.Logger, o.GetName(), func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPost, o.APIBaseURL+path, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "GenieKey "+o.APIKey)
+		return req, nil
+	}, nil)
+	if err != nil {
+		return err
+	}
+	if status >= 200 && status < 300 {
+		return nil
+	}
+	var result model.OpsgenieResponse
+	if json.Unmarshal(data, &result) == nil && result.Message != "" {
+		return fmt.Errorf("status %d: %s", status, result.Message)
+	}
+	return fmt.Errorf("status %d: %s", status, truncate(strings.TrimSpace(string(data)), 200))
+}
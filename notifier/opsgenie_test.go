@@ -0,0 +1,146 @@
+package notifier_test
+
+import (
+	"encoding/json"
+	"healthy-api/model"
+	"healthy-api/notifier"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type opsgenieRequest struct {
+	path string
+	body map[string]any
+}
+
+func TestOpsgenieNotifier_Lifecycle(t *testing.T) {
+	var requests []opsgenieRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "GenieKey test-key" {
+			t.Errorf("unexpected authorization header %q", got)
+		}
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode request body: %v", err)
+		}
+		requests = append(requests, opsgenieRequest{path: r.URL.RequestURI(), body: body})
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"result":"Request will be processed","took":0.01,"requestId":"r1"}`))
+	}))
+	defer server.Close()
+	og, err := notifier.NewOpsgenieNotifier(model.Opsgenie{
+		ID:         "og",
+		APIKey:     "test-key",
+		APIBaseURL: server.URL,
+		Responders: []string{"team:Payments"},
+		Tags:       []string{"prod"},
+		Priorities: map[model.Severity]string{model.SeverityWarning: "P4"},
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	alert := model.Notification{
+		ServiceName: "Checkout API",
+		Recipients:  []string{"user:oncall@my-company.com"},
+		Reason:      "Response time 900ms exceeded limit 800ms",
+		StatusCode:  200,
+		State:       model.StateDegraded,
+		Severity:    model.SeverityWarning,
+		Trace: &model.TraceNode{Path: "condition", Children: []*model.TraceNode{
+			{Path: "condition.and[0]", Passed: true},
+			{Path: "condition.and[1]", Reason: "Response time 900ms exceeded limit 800ms"},
+		}},
+	}
+	slower := alert
+	slower.Reason = "Response time 950ms exceeded limit 800ms"
+	changed := alert
+	changed.Reason = "Status code 503"
+	changed.State = model.StateDown
+	changed.Severity = model.SeverityCritical
+	changed.Trace = &model.TraceNode{Path: "condition", Children: []*model.TraceNode{
+		{Path: "condition.and[0]", Reason: "Status code 503"},
+		{Path: "condition.and[1]", Skipped: true},
+	}}
+	for _, err := range []error{
+		og.Notify(alert),
+		og.Notify(alert),
+		og.Notify(slower),
+		og.Notify(changed),
+		og.Resolve(model.Notification{ServiceName: "Checkout API", State: model.StateUp, Reason: "Service is healthy again"}),
+	} {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	var paths []string
+	for _, r := range requests {
+		paths = append(paths, r.path)
+	}
+	want := []string{
+		"/v2/alerts",
+		"/v2/alerts",
+		"/v2/alerts",
+		"/v2/alerts",
+		"/v2/alerts/Checkout%20API/notes?identifierType=alias",
+		"/v2/alerts/Checkout%20API/close?identifierType=alias",
+	}
+	if len(paths) != len(want) {
+		t.Fatalf("unexpected requests: %v", paths)
+	}
+	for i := range want {
+		if paths[i] != want[i] {
+			t.Errorf("request %d: expected %s, got %s", i, want[i], paths[i])
+		}
+	}
+
+	first := requests[0].body
+	if first["alias"] != "Checkout API" || first["priority"] != "P4" || first["entity"] != "Checkout API" {
+		t.Errorf("unexpected alert: %v", first)
+	}
+	responders, _ := first["responders"].([]any)
+	if len(responders) != 2 {
+		t.Errorf("expected configured and target responders, got %v", first["responders"])
+	}
+	if details, _ := first["details"].(map[string]any); details["status_code"] != "200" {
+		t.Errorf("unexpected details: %v", first["details"])
+	}
+	if requests[3].body["priority"] != "P1" {
+		t.Errorf("critical alerts should be P1, got %v", requests[3].body["priority"])
+	}
+	if requests[4].body["note"] != "Reason changed: Status code 503" {
+		t.Errorf("unexpected note: %v", requests[4].body)
+	}
+}
+
+func TestNewOpsgenieNotifier_RejectsBadConfig(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cases := []model.Opsgenie{
+		{ID: "no-key"},
+		{ID: "responder", APIKey: "k", Responders: []string{"group:ops"}},
+		{ID: "priority", APIKey: "k", Priorities: map[model.Severity]string{model.SeverityCritical: "P0"}},
+	}
+	for _, cfg := range cases {
+		if _, err := notifier.NewOpsgenieNotifier(cfg, logger); err == nil {
+			t.Errorf("%s: expected an error", cfg.ID)
+		}
+	}
+}
+
+func TestOpsgenieNotifier_ValidateRecipients(t *testing.T) {
+	og, err := notifier.NewOpsgenieNotifier(model.Opsgenie{ID: "og", APIKey: "k"}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var rv notifier.RecipientValidator = og
+	if err := rv.ValidateRecipients([]string{"team:Payments", "user:oncall@my-company.com"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := rv.ValidateRecipients([]string{"teams:Payments"}); err == nil {
+		t.Error("expected an error for an unknown responder type")
+	}
+}
@@ -0,0 +1,146 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"healthy-api/model"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultPagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+	// pagerDutyMaxSummary is the Events API limit for payload.summary.
+	pagerDutyMaxSummary = 1024
+)
+
+// PagerDutyNotifier triggers and resolves PagerDuty incidents. Every
+// service has one dedup key, so repeated alerts update the open incident
+// and the recovery resolves it.
+type PagerDutyNotifier struct {
+	ID        string
+	EventsURL string
+	Source    string
+	Component string
+	Group     string
+	Class     string
+	Client    *http.Client
+	Logger    *slog.Logger
+}
+
+func NewPagerDutyNotifier(cfg model.PagerDuty, logger *slog.Logger) *PagerDutyNotifier {
+	p := &PagerDutyNotifier{
+		ID:        cfg.ID,
+		EventsURL: cfg.EventsURL,
+		Source:    cfg.Source,
+		Component: cfg.Component,
+		Group:     cfg.Group,
+		Class:     cfg.Class,
+		Client:    newHTTPClient(),
+		Logger:    logger,
+	}
+	if p.EventsURL == "" {
+		p.EventsURL = DefaultPagerDutyEventsURL
+	}
+	if p.Source == "" {
+		p.Source = "healthy-api"
+	}
+	return p
+}
+
+func (p *PagerDutyNotifier) GetName() string {
+	return fmt.Sprintf("PagerDutyNotifier(%s)", p.ID)
+}
+
+// DedupKey identifies the incident of a service.
+func (p *PagerDutyNotifier) DedupKey(service string) string {
+	return "healthy-api/" + service
+}
+
+func (p *PagerDutyNotifier) Notify(n model.Notification) error {
+	component := p.Component
+	if component == "" {
+		component = n.ServiceName
+	}
+	severity := "critical"
+	if n.Severity == model.SeverityWarning {
+		severity = "warning"
+	}
+	details := map[string]any{
+		"state":         n.State,
+		"reason":        n.Reason,
+		"status_code":   n.StatusCode,
+		"response_time": n.ResponseTime,
+	}
+	if n.Timing != (model.Timing{}) {
+		details["timing"] = n.Timing.String()
+	}
+	if n.Trace != nil {
+		details["trace"] = n.Trace
+	}
+	summary := fmt.Sprintf("%s is %s: %s", n.ServiceName, n.State, firstLine(n.Reason))
+	return p.send(n, model.PagerDutyEvent{
+		EventAction: "trigger",
+		DedupKey:    p.DedupKey(n.ServiceName),
+		Client:      "Healthy-API",
+		Payload: &model.PagerDutyPayload{
+			Summary:       truncate(summary, pagerDutyMaxSummary),
+			Source:        p.Source,
+			Severity:      severity,
+			Timestamp:     time.Now().UTC().Format(time.RFC3339),
+			Component:     component,
+			Group:         p.Group,
+			Class:         p.Class,
+			CustomDetails: details,
+		},
+	})
+}
+
+// Resolve resolves the service's incident.
+func (p *PagerDutyNotifier) Resolve(n model.Notification) error {
+	return p.send(n, model.PagerDutyEvent{
+		EventAction: "resolve",
+		DedupKey:    p.DedupKey(n.ServiceName),
+	})
+}
+
+func (p *PagerDutyNotifier) send(n model.Notification, event model.PagerDutyEvent) error {
+	attrs := []any{"action", event.EventAction, "dedup_key", event.DedupKey}
+	return sendToEach(p.Logger, p.ID, "routing_key", n.Recipients, "pagerduty_event_failed", "pagerduty_event_sent", attrs, func(routingKey string) error {
+		event.RoutingKey = routingKey
+		return p.enqueue(event)
+	})
+}
+
+func (p *PagerDutyNotifier) enqueue(event model.PagerDutyEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	status, data, err := sendWithRetry(p.Client, p.Logger, p.GetName(), func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPost, p.EventsURL, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	}, nil)
+	if err != nil {
+		return err
+	}
+	if status >= 200 && status < 300 {
+		return nil
+	}
+	var result model.PagerDutyResponse
+	if json.Unmarshal(data, &result) == nil && result.Message != "" {
+		if len(result.Errors) > 0 {
+			return fmt.Errorf("status %d: %s: %s", status, result.Message, strings.Join(result.Errors, "; "))
+		}
+		return fmt.Errorf("status %d: %s", status, result.Message)
+	}
+	return fmt.Errorf("status %d: %s", status, truncate(strings.TrimSpace(string(data)), 200))
+}
@@ -0,0 +1,83 @@
+package notifier_test
+
+import (
+	"encoding/json"
+	"healthy-api/model"
+	"healthy-api/notifier"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPagerDutyNotifier_TriggerAndResolve(t *testing.T) {
+	var events []model.PagerDutyEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event model.PagerDutyEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("failed to decode request body: %v", err)
+		}
+		events = append(events, event)
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"status":"success","message":"Event processed","dedup_key":"` + event.DedupKey + `"}`))
+	}))
+	defer server.Close()
+	pd := notifier.NewPagerDutyNotifier(model.PagerDuty{ID: "pd", EventsURL: server.URL, Group: "payments"}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	err := pd.Notify(model.Notification{
+		ServiceName:  "Checkout API",
+		Recipients:   []string{"R0UTINGKEY"},
+		Reason:       "Body changed from baseline:\n--- baseline",
+		StatusCode:   200,
+		ResponseTime: "900ms",
+		State:        model.StateDegraded,
+		Severity:     model.SeverityWarning,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = pd.Resolve(model.Notification{ServiceName: "Checkout API", Recipients: []string{"R0UTINGKEY"}, State: model.StateUp})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	trigger, resolve := events[0], events[1]
+	if trigger.EventAction != "trigger" || trigger.RoutingKey != "R0UTINGKEY" || trigger.Payload == nil {
+		t.Fatalf("unexpected trigger event: %+v", trigger)
+	}
+	if trigger.DedupKey == "" || trigger.DedupKey != resolve.DedupKey {
+		t.Errorf("trigger and resolve should share the dedup key: %q, %q", trigger.DedupKey, resolve.DedupKey)
+	}
+	p := trigger.Payload
+	if p.Summary != "Checkout API is DEGRADED: Body changed from baseline:" {
+		t.Errorf("unexpected summary %q", p.Summary)
+	}
+	if p.Severity != "warning" || p.Source != "healthy-api" || p.Component != "Checkout API" || p.Group != "payments" {
+		t.Errorf("unexpected payload: %+v", p)
+	}
+	if p.CustomDetails["status_code"] != float64(200) || p.CustomDetails["response_time"] != "900ms" {
+		t.Errorf("unexpected custom details: %+v", p.CustomDetails)
+	}
+	if resolve.EventAction != "resolve" || resolve.Payload != nil {
+		t.Errorf("unexpected resolve event: %+v", resolve)
+	}
+}
+
+func TestPagerDutyNotifier_InvalidEvent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"status":"invalid event","message":"Event object is invalid","errors":["Length of 'routing_key' is incorrect (should be 32 characters)"]}`))
+	}))
+	defer server.Close()
+	pd := notifier.NewPagerDutyNotifier(model.PagerDuty{ID: "pd", EventsURL: server.URL}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	err := pd.Notify(model.Notification{ServiceName: "api", Recipients: []string{"short"}, State: model.StateDown})
+	if err == nil || !strings.Contains(err.Error(), "routing_key") {
+		t.Fatalf("expected an invalid event error, got %v", err)
+	}
+}
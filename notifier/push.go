@@ -0,0 +1,60 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"healthy-api/model"
+	"net/http"
+	"strings"
+)
+
+// pushMessageTemplate is the default body of push notifications; the state
+// is already in the title.
+const pushMessageTemplate = "{{.Reason}}" +
+	"{{if .StatusCode}}\nStatus code: {{.StatusCode}}{{end}}" +
+	"{{if .ResponseTime}}\nResponse time: {{.ResponseTime}}{{end}}"
+
+// setPushAuth adds a bearer token or basic auth to req.
+func setPushAuth(req *http.Request, auth model.PushAuth) {
+	switch {
+	case auth.AccessToken != "":
+		req.Header.Set("Authorization", "Bearer "+auth.AccessToken)
+	case auth.Username != "":
+		req.SetBasicAuth(auth.Username, auth.Password)
+	}
+}
+
+// pushPriorities overrides the defaults with the configured priorities,
+// checking that they are within [lo, hi].
+func pushPriorities(defaults, configured map[model.Severity]int, lo, hi int) (map[model.Severity]int, error) {
+	priorities := make(map[model.Severity]int, len(defaults))
+	for severity, p := range defaults {
+		priorities[severity] = p
+	}
+	for severity, p := range configured {
+		if err := severity.Validate(); err != nil || severity == "" {
+			return nil, fmt.Errorf("priorities: unknown severity '%s'", severity)
+		}
+		if p < lo || p > hi {
+			return nil, fmt.Errorf("priorities: %s priority %d is not between %d and %d", severity, p, lo, hi)
+		}
+		priorities[severity] = p
+	}
+	return priorities, nil
+}
+
+// pushError describes a failed response, using the JSON "error" field that
+// ntfy and Gotify both return.
+func pushError(status int, data []byte) error {
+	var result struct {
+		Error            string `json:"error"`
+		ErrorDescription string `json:"errorDescription"`
+	}
+	if json.Unmarshal(data, &result) == nil && result.Error != "" {
+		if result.ErrorDescription != "" {
+			return fmt.Errorf("status %d: %s: %s", status, result.Error, result.ErrorDescription)
+		}
+		return fmt.Errorf("status %d: %s", status, result.Error)
+	}
+	return fmt.Errorf("status %d: %s", status, truncate(strings.TrimSpace(string(data)), 200))
+}
@@ -0,0 +1,155 @@
+package notifier_test
+
+import (
+	"encoding/json"
+	"healthy-api/model"
+	"healthy-api/notifier"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNtfyNotifier_Publish(t *testing.T) {
+	var received []model.NtfyMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "monitor" || pass != "secret" {
+			t.Errorf("expected basic auth, got %q", r.Header.Get("Authorization"))
+		}
+		var msg model.NtfyMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Errorf("failed to decode request body: %v", err)
+		}
+		received = append(received, msg)
+		w.Write([]byte(`{"id":"abc","event":"message"}`))
+	}))
+	defer server.Close()
+	nf, err := notifier.NewNtfyNotifier(model.Ntfy{
+		ID:         "ntfy",
+		ServerURL:  server.URL + "/",
+		Topic:      "alerts",
+		PushAuth:   model.PushAuth{Username: "monitor", Password: "secret"},
+		Priorities: map[model.Severity]int{model.SeverityWarning: 2},
+		Tags:       []string{"prod"},
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, n := range []model.Notification{
+		{ServiceName: "api", URL: "https://api.example.com/health", Reason: "Status code 503", StatusCode: 503, State: model.StateDown, Severity: model.SeverityCritical},
+		{ServiceName: "api", Recipients: []string{"team-a"}, Reason: "slow", State: model.StateDegraded, Severity: model.SeverityWarning},
+	} {
+		if err := nf.Notify(n); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if len(received) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(received))
+	}
+	down := received[0]
+	if down.Topic != "alerts" || down.Title != "api is DOWN" || down.Priority != 5 || down.Click != "https://api.example.com/health" {
+		t.Errorf("unexpected message: %+v", down)
+	}
+	if !strings.HasPrefix(down.Message, "Status code 503\nStatus code: 503") {
+		t.Errorf("unexpected body %q", down.Message)
+	}
+	if len(down.Tags) != 2 || down.Tags[0] != "rotating_light" || down.Tags[1] != "prod" {
+		t.Errorf("unexpected tags: %v", down.Tags)
+	}
+	if warn := received[1]; warn.Topic != "team-a" || warn.Priority != 2 || warn.Tags[0] != "warning" {
+		t.Errorf("unexpected warning message: %+v", warn)
+	}
+}
+
+func TestGotifyNotifier_Send(t *testing.T) {
+	var received model.GotifyMessage
+	var token, auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/message" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		token = r.Header.Get("X-Gotify-Key")
+		auth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("failed to decode request body: %v", err)
+		}
+		w.Write([]byte(`{"id":1}`))
+	}))
+	defer server.Close()
+	gf, err := notifier.NewGotifyNotifier(model.Gotify{
+		ID:        "gotify",
+		ServerURL: server.URL,
+		AppToken:  "AppToken1",
+		PushAuth:  model.PushAuth{AccessToken: "proxy-token"},
+		ClickURL:  "https://status.example.com",
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = gf.Resolve(model.Notification{ServiceName: "api", Reason: "Service is healthy again", State: model.StateUp})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if token != "AppToken1" || auth != "Bearer proxy-token" {
+		t.Errorf("unexpected auth: token %q, authorization %q", token, auth)
+	}
+	if received.Title != "🟢 api is UP" || received.Priority != 4 {
+		t.Errorf("unexpected message: %+v", received)
+	}
+	click, _ := received.Extras["client::notification"].(map[string]any)["click"].(map[string]any)
+	if click["url"] != "https://status.example.com" {
+		t.Errorf("unexpected extras: %v", received.Extras)
+	}
+}
+
+func TestGotifyNotifier_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":"Unauthorized","errorCode":401,"errorDescription":"you need to provide a valid access token or user credentials to access this api"}`))
+	}))
+	defer server.Close()
+	gf, err := notifier.NewGotifyNotifier(model.Gotify{ID: "gotify", ServerURL: server.URL}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = gf.Notify(model.Notification{ServiceName: "api", Recipients: []string{"bad"}, State: model.StateDown})
+	if err == nil || !strings.Contains(err.Error(), "valid access token") {
+		t.Fatalf("expected an auth error, got %v", err)
+	}
+	if strings.Contains(err.Error(), "bad") {
+		t.Errorf("error should not contain the app token: %v", err)
+	}
+}
+
+func TestPushNotifiers_NoDestination(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	nt, err := notifier.NewNtfyNotifier(model.Ntfy{ID: "ntfy", ServerURL: "http://ntfy"}, logger)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := nt.Notify(model.Notification{ServiceName: "api", State: model.StateDown}); err == nil || !strings.Contains(err.Error(), "no topic") {
+		t.Errorf("expected a missing topic error, got %v", err)
+	}
+	gf, err := notifier.NewGotifyNotifier(model.Gotify{ID: "gotify", ServerURL: "http://gotify"}, logger)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := gf.Notify(model.Notification{ServiceName: "api", State: model.StateDown}); err == nil || !strings.Contains(err.Error(), "no app token") {
+		t.Errorf("expected a missing app token error, got %v", err)
+	}
+}
+
+func TestPushNotifiers_RejectBadPriorities(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if _, err := notifier.NewNtfyNotifier(model.Ntfy{ID: "ntfy", ServerURL: "http://ntfy", Priorities: map[model.Severity]int{model.SeverityCritical: 6}}, logger); err == nil {
+		t.Error("ntfy priority 6 should be rejected")
+	}
+	if _, err := notifier.NewGotifyNotifier(model.Gotify{ID: "gotify", ServerURL: "http://gotify", Priorities: map[model.Severity]int{"urgent": 9}}, logger); err == nil {
+		t.Error("unknown severity should be rejected")
+	}
+}
@@ -0,0 +1,189 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"healthy-api/model"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const DefaultSlackAPIBaseURL = "https://slack.com/api"
+
+var slackColors = map[model.ServiceState]string{
+	model.StateUp:       "#2eb886",
+	model.StateDegraded: "#daa038",
+	model.StateDown:     "#a30200",
+}
+
+// SlackNotifier posts alerts to Slack. With a bot token it remembers the ts
+// of each alert so follow-ups and the recovery go to the alert's thread.
+type SlackNotifier struct {
+	ID         string
+	BotToken   string
+	APIBaseURL string
+	Template   *MessageTemplate
+	Client     *http.Client
+	Logger     *slog.Logger
+
+	mu      sync.Mutex
+	threads map[string]string // service and channel -> ts of the open alert
+}
+
+func NewSlackNotifier(cfg model.Slack, logger *slog.Logger) (*SlackNotifier, error) {
+	tmpl, err := NewMessageTemplate(cfg.Template, MarkupSlack)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	baseURL := cfg.APIBaseURL
+	if baseURL == "" {
+		baseURL = DefaultSlackAPIBaseURL
+	}
+	return &SlackNotifier{
+		ID:         cfg.ID,
+		BotToken:   cfg.BotToken,
+		APIBaseURL: strings.TrimRight(baseURL, "/"),
+		Template:   tmpl,
+		Client:     newHTTPClient(),
+		Logger:     logger,
+		threads:    make(map[string]string),
+	}, nil
+}
+
+func (s *SlackNotifier) GetName() string {
+	return fmt.Sprintf("SlackNotifier(%s)", s.ID)
+}
+
+func (s *SlackNotifier) Notify(n model.Notification) error {
+	return s.post(n, false)
+}
+
+// Resolve posts the recovery; with a bot token it is a reply in the thread
+// of the alert, which is then closed.
+func (s *SlackNotifier) Resolve(n model.Notification) error {
+	return s.post(n, true)
+}
+
+func (s *SlackNotifier) post(n model.Notification, resolve bool) error {
+	msg, err := s.message(n)
+	if err != nil {
+		return err
+	}
+	// Bots post to channel IDs, which are not secret.
+	kind := "webhook"
+	if s.BotToken != "" {
+		kind = ""
+	}
+	attrs := []any{"service", n.ServiceName, "state", n.State}
+	return sendToEach(s.Logger, s.ID, kind, n.Recipients, "slack_send_failed", "slack_sent", attrs, func(recipient string) error {
+		if s.BotToken == "" {
+			return s.sendWebhook(recipient, msg)
+		}
+		return s.sendBot(n.ServiceName, recipient, msg, resolve)
+	})
+}
+
+func (s *SlackNotifier) message(n model.Notification) (model.SlackMessage, error) {
+	text, err := s.Template.Render(n)
+	if err != nil {
+		return model.SlackMessage{}, fmt.Errorf("failed to render slack message: %w", err)
+	}
+	esc := MarkupSlack.Escape
+	fields := []model.SlackField{{Title: "Reason", Value: esc(truncate(n.Reason, maxMessageReason))}}
+	if n.StatusCode != 0 {
+		fields = append(fields, model.SlackField{Title: "Status code", Value: strconv.Itoa(n.StatusCode), Short: true})
+	}
+	if n.ResponseTime != "" {
+		fields = append(fields, model.SlackField{Title: "Response time", Value: esc(n.ResponseTime), Short: true})
+	}
+	if n.Severity != "" {
+		fields = append(fields, model.SlackField{Title: "Severity", Value: string(n.Severity), Short: true})
+	}
+	if trace := n.Trace.String(); trace != "" {
+		fields = append(fields, model.SlackField{Title: "Trace", Value: "```" + esc(truncate(trace, maxMessageTrace)) + "```"})
+	}
+	color, ok := slackColors[n.State]
+	if !ok {
+		color = slackColors[model.StateDown]
+	}
+	return model.SlackMessage{
+		Text: text,
+		Attachments: []model.SlackAttachment{{
+			Color:    color,
+			Fields:   fields,
+			Footer:   "Healthy-API",
+			TS:       time.Now().Unix(),
+			MrkdwnIn: []string{"fields"},
+		}},
+	}, nil
+}
+
+func (s *SlackNotifier) sendWebhook(hookURL string, msg model.SlackMessage) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal slack message: %w", err)
+	}
+	status, data, err := sendWithRetry(s.Client, s.Logger, s.GetName(), func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPost, hookURL, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	}, nil)
+	if err != nil {
+		return err
+	}
+	if text := strings.TrimSpace(string(data)); status != http.StatusOK || text != "ok" {
+		return fmt.Errorf("status %d: %s", status, truncate(text, 200))
+	}
+	return nil
+}
+
+func (s *SlackNotifier) sendBot(service, channel string, msg model.SlackMessage, resolve bool) error {
+	key := service + "\x00" + channel
+	s.mu.Lock()
+	ts := s.threads[key]
+	s.mu.Unlock()
+
+	msg.Channel = channel
+	msg.ThreadTS = ts
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal slack message: %w", err)
+	}
+	_, data, err := sendWithRetry(s.Client, s.Logger, s.GetName(), func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPost, s.APIBaseURL+"/chat.postMessage", bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json; charset=utf-8")
+		req.Header.Set("Authorization", "Bearer "+s.BotToken)
+		return req, nil
+	}, nil)
+	if err != nil {
+		return fmt.Errorf("chat.postMessage: %w", err)
+	}
+	var result model.SlackResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("chat.postMessage: unexpected response: %s", truncate(string(data), 200))
+	}
+	if !result.OK {
+		return fmt.Errorf("chat.postMessage: %s", result.Error)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case resolve:
+		delete(s.threads, key)
+	case ts == "":
+		s.threads[key] = result.TS
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package notifier_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"healthy-api/model"
+	"healthy-api/notifier"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newSlackNotifier(t *testing.T, cfg model.Slack) *notifier.SlackNotifier {
+	t.Helper()
+	sl, err := notifier.NewSlackNotifier(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return sl
+}
+
+func TestSlackNotifier_Webhook(t *testing.T) {
+	var received model.SlackMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("failed to decode request body: %v", err)
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	sl := newSlackNotifier(t, model.Slack{ID: "slack"})
+
+	err := sl.Notify(model.Notification{
+		ServiceName:  "<api>",
+		Recipients:   []string{server.URL},
+		Reason:       "Status code 503 & slow",
+		StatusCode:   503,
+		ResponseTime: "2s",
+		State:        model.StateDegraded,
+		Severity:     model.SeverityWarning,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if received.Text != "🟠 *&lt;api&gt;* is *DEGRADED*" {
+		t.Errorf("unexpected text: %q", received.Text)
+	}
+	if len(received.Attachments) != 1 {
+		t.Fatalf("expected one attachment, got %+v", received.Attachments)
+	}
+	att := received.Attachments[0]
+	if att.Color != "#daa038" {
+		t.Errorf("unexpected color %s", att.Color)
+	}
+	fields := map[string]string{}
+	for _, f := range att.Fields {
+		fields[f.Title] = f.Value
+	}
+	if fields["Reason"] != "Status code 503 &amp; slow" || fields["Status code"] != "503" || fields["Response time"] != "2s" {
+		t.Errorf("unexpected fields: %+v", att.Fields)
+	}
+}
+
+func TestSlackNotifier_WebhookError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("no_service"))
+	}))
+	defer server.Close()
+	sl := newSlackNotifier(t, model.Slack{ID: "slack"})
+
+	err := sl.Notify(model.Notification{ServiceName: "api", Recipients: []string{server.URL}, State: model.StateDown})
+	if err == nil || !strings.Contains(err.Error(), "no_service") {
+		t.Fatalf("expected no_service error, got %v", err)
+	}
+	if strings.Contains(err.Error(), server.URL) {
+		t.Errorf("error should not contain the webhook URL: %v", err)
+	}
+}
+
+func TestSlackNotifier_ThreadsRecovery(t *testing.T) {
+	var received []model.SlackMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat.postMessage" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer xoxb-test" {
+			t.Errorf("unexpected authorization header %q", got)
+		}
+		var msg model.SlackMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			t.Errorf("failed to decode request body: %v", err)
+		}
+		received = append(received, msg)
+		fmt.Fprintf(w, `{"ok":true,"channel":"C1","ts":"1700000000.%06d"}`, len(received))
+	}))
+	defer server.Close()
+	sl := newSlackNotifier(t, model.Slack{ID: "slack", BotToken: "xoxb-test", APIBaseURL: server.URL + "/api"})
+
+	alert := model.Notification{ServiceName: "api", Recipients: []string{"C1"}, State: model.StateDown, Reason: "down"}
+	recovery := model.Notification{ServiceName: "api", Recipients: []string{"C1"}, State: model.StateUp, Reason: "healthy again"}
+	steps := []error{sl.Notify(alert), sl.Notify(alert), sl.Resolve(recovery), sl.Notify(alert)}
+	for _, err := range steps {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if len(received) != 4 {
+		t.Fatalf("expected 4 messages, got %d", len(received))
+	}
+	if received[0].Channel != "C1" || received[0].ThreadTS != "" {
+		t.Errorf("the first alert should start a thread: %+v", received[0])
+	}
+	if received[1].ThreadTS != "1700000000.000001" {
+		t.Errorf("a repeated alert should go to the thread, got thread_ts %q", received[1].ThreadTS)
+	}
+	if received[2].ThreadTS != "1700000000.000001" || received[2].Attachments[0].Color != "#2eb886" {
+		t.Errorf("the recovery should be a green reply in the thread: %+v", received[2])
+	}
+	if received[3].ThreadTS != "" {
+		t.Errorf("an alert after the recovery should start a new thread, got thread_ts %q", received[3].ThreadTS)
+	}
+}
+
+func TestSlackNotifier_APIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok":false,"error":"channel_not_found"}`))
+	}))
+	defer server.Close()
+	sl := newSlackNotifier(t, model.Slack{ID: "slack", BotToken: "xoxb-test", APIBaseURL: server.URL})
+
+	err := sl.Notify(model.Notification{ServiceName: "api", Recipients: []string{"C404"}, State: model.StateDown})
+	if err == nil || !strings.Contains(err.Error(), "channel_not_found") {
+		t.Fatalf("expected channel_not_found error, got %v", err)
+	}
+}
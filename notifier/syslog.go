@@ -0,0 +1,270 @@
+package notifier
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"healthy-api/model"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	syslogDialTimeout  = 10 * time.Second
+	syslogWriteTimeout = 10 * time.Second
+
+	// syslogTimestamp is RFC 3339 with the microseconds RFC 5424 allows.
+	syslogTimestamp = "2006-01-02T15:04:05.000000Z07:00"
+	// syslogBOM marks MSG as UTF-8.
+	syslogBOM = "\xef\xbb\xbf"
+)
+
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5,
+	"lpr": 6, "news": 7, "uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"ntp": 12, "security": 13, "console": 14,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// Syslog severities used for alerts.
+const (
+	syslogCritical = 2
+	syslogWarning  = 4
+	syslogNotice   = 5
+)
+
+// SyslogNotifier writes RFC 5424 messages to a collector. Stream
+// connections are kept open and redialed when a write fails.
+type SyslogNotifier struct {
+	ID        string
+	Network   string
+	Address   string
+	Facility  int
+	Hostname  string
+	AppName   string
+	SDID      string
+	TLSConfig *tls.Config
+	Logger    *slog.Logger
+
+	procID string
+	mu     sync.Mutex
+	conn   net.Conn
+}
+
+func NewSyslogNotifier(cfg model.Syslog, logger *slog.Logger) (*SyslogNotifier, error) {
+	if cfg.Address == "" {
+		return nil, fmt.Errorf("address is required")
+	}
+	network := cfg.Network
+	switch network {
+	case "":
+		network = "udp"
+	case "udp", "tcp", "tls":
+	default:
+		return nil, fmt.Errorf("unknown network '%s', use udp, tcp or tls", cfg.Network)
+	}
+	facilityName := cfg.Facility
+	if facilityName == "" {
+		facilityName = "daemon"
+	}
+	facility, ok := syslogFacilities[facilityName]
+	if !ok {
+		return nil, fmt.Errorf("unknown facility '%s'", cfg.Facility)
+	}
+	sdID := cfg.SDID
+	if sdID == "" {
+		sdID = "healthcheck@32473"
+	}
+	if !validSyslogName(sdID, 32) {
+		return nil, fmt.Errorf("invalid sd_id '%s'", cfg.SDID)
+	}
+	var tlsConfig *tls.Config
+	if network == "tls" {
+		var err error
+		if tlsConfig, err = syslogTLSConfig(cfg); err != nil {
+			return nil, err
+		}
+	} else if cfg.TLS != nil {
+		return nil, fmt.Errorf("tls is only used with network tls")
+	}
+	hostname := cfg.Hostname
+	if hostname == "" {
+		hostname, _ = os.Hostname()
+	}
+	appName := cfg.AppName
+	if appName == "" {
+		appName = "healthy-api"
+	}
+	return &SyslogNotifier{
+		ID:        cfg.ID,
+		Network:   network,
+		Address:   cfg.Address,
+		Facility:  facility,
+		Hostname:  syslogHeaderField(hostname, 255),
+		AppName:   syslogHeaderField(appName, 48),
+		SDID:      sdID,
+		TLSConfig: tlsConfig,
+		Logger:    logger,
+		procID:    strconv.Itoa(os.Getpid()),
+	}, nil
+}
+
+func syslogTLSConfig(cfg model.Syslog) (*tls.Config, error) {
+	conf := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.TLS == nil {
+		return conf, nil
+	}
+	conf.ServerName = cfg.TLS.ServerName
+	conf.InsecureSkipVerify = cfg.TLS.InsecureSkipVerify
+	if cfg.TLS.CAFile != "" {
+		pem, err := os.ReadFile(cfg.TLS.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("tls: %w", err)
+		}
+		conf.RootCAs = x509.NewCertPool()
+		if !conf.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("tls: no certificates in %s", cfg.TLS.CAFile)
+		}
+	}
+	if cfg.TLS.CertFile != "" || cfg.TLS.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("tls: %w", err)
+		}
+		conf.Certificates = []tls.Certificate{cert}
+	}
+	return conf, nil
+}
+
+func (s *SyslogNotifier) GetName() string {
+	return fmt.Sprintf("SyslogNotifier(%s)", s.ID)
+}
+
+// Resolve emits the recovery at notice severity.
+func (s *SyslogNotifier) Resolve(n model.Notification) error {
+	return s.Notify(n)
+}
+
+func (s *SyslogNotifier) Notify(n model.Notification) error {
+	msg := s.format(n, time.Now())
+	if err := s.write(msg); err != nil {
+		s.Logger.Error("syslog_send_failed", "notifier", s.ID, "network", s.Network, "address", s.Address, "error", err)
+		return err
+	}
+	s.Logger.Info("syslog_sent", "notifier", s.ID, "network", s.Network, "address", s.Address, "service", n.ServiceName)
+	return nil
+}
+
+// format builds the RFC 5424 message:
+//
+//	<PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [SD-ID param="value"...] BOM MSG
+func (s *SyslogNotifier) format(n model.Notification, now time.Time) []byte {
+	severity := syslogCritical
+	switch {
+	case n.State == model.StateUp:
+		severity = syslogNotice
+	case n.Severity == model.SeverityWarning:
+		severity = syslogWarning
+	}
+
+	var sd strings.Builder
+	sd.WriteString("[" + s.SDID)
+	param := func(name, value string) {
+		if value != "" {
+			sd.WriteString(" " + name + `="` + syslogParamReplacer.Replace(value) + `"`)
+		}
+	}
+	param("service", n.ServiceName)
+	param("url", n.URL)
+	param("state", string(n.State))
+	param("severity", string(n.Severity))
+	param("reason", strings.ReplaceAll(truncate(n.Reason, maxMessageReason), "\n", " "))
+	if n.StatusCode != 0 {
+		param("status_code", strconv.Itoa(n.StatusCode))
+	}
+	param("response_time", n.ResponseTime)
+	sd.WriteString("]")
+
+	text := fmt.Sprintf("%s is %s: %s", n.ServiceName, n.State, firstLine(n.Reason))
+	return []byte(fmt.Sprintf("<%d>1 %s %s %s %s %s %s %s%s",
+		s.Facility*8+severity,
+		now.UTC().Format(syslogTimestamp),
+		s.Hostname, s.AppName, s.procID,
+		syslogHeaderField(string(n.State), 32),
+		sd.String(), syslogBOM, text))
+}
+
+// write sends msg, framed with its length on stream connections. A failed
+// write on a kept-open connection is retried once on a new one, since the
+// collector may have closed it in the meantime.
+func (s *SyslogNotifier) write(msg []byte) error {
+	if s.Network != "udp" {
+		msg = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if s.conn == nil {
+			if s.conn, err = s.dial(); err != nil {
+				return fmt.Errorf("dial: %w", err)
+			}
+		}
+		s.conn.SetWriteDeadline(time.Now().Add(syslogWriteTimeout))
+		if _, err = s.conn.Write(msg); err == nil {
+			return nil
+		}
+		s.conn.Close()
+		s.conn = nil
+	}
+	return fmt.Errorf("write: %w", err)
+}
+
+func (s *SyslogNotifier) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: syslogDialTimeout}
+	if s.Network == "tls" {
+		return tls.DialWithDialer(dialer, "tcp", s.Address, s.TLSConfig)
+	}
+	return dialer.Dial(s.Network, s.Address)
+}
+
+// syslogParamReplacer escapes PARAM-VALUE characters (RFC 5424 6.3.3).
+var syslogParamReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// syslogHeaderField fits s into a HEADER field: printable ASCII without
+// spaces, at most limit characters, "-" when empty.
+func syslogHeaderField(s string, limit int) string {
+	field := strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return '_'
+		}
+		return r
+	}, s)
+	if len(field) > limit {
+		field = field[:limit]
+	}
+	if field == "" {
+		return "-"
+	}
+	return field
+}
+
+// validSyslogName reports whether s is a valid SD-NAME: printable ASCII
+// without '=', ' ', ']' or '"', at most limit characters.
+func validSyslogName(s string, limit int) bool {
+	if s == "" || len(s) > limit {
+		return false
+	}
+	for _, r := range s {
+		if r <= ' ' || r > '~' || r == '=' || r == ']' || r == '"' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,240 @@
+package notifier_test
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"healthy-api/model"
+	"healthy-api/notifier"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var syslogDown = model.Notification{
+	ServiceName:  "api",
+	URL:          "https://api.example.com/health",
+	Reason:       `Body "status" is not "ok" [got "fail"]` + "\nsecond line",
+	StatusCode:   503,
+	ResponseTime: "1.2s",
+	State:        model.StateDown,
+	Severity:     model.SeverityCritical,
+}
+
+func newSyslogNotifier(t *testing.T, cfg model.Syslog) *notifier.SyslogNotifier {
+	t.Helper()
+	cfg.ID = "siem"
+	cfg.Hostname = "monitor-1"
+	sl, err := notifier.NewSyslogNotifier(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return sl
+}
+
+// readOctetCounted reads one "LEN SP MSG" frame.
+func readOctetCounted(r *bufio.Reader) (string, error) {
+	length, err := r.ReadString(' ')
+	if err != nil {
+		return "", err
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(length, " "))
+	if err != nil {
+		return "", err
+	}
+	msg := make([]byte, n)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return "", err
+	}
+	return string(msg), nil
+}
+
+func TestSyslogNotifier_UDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer conn.Close()
+	sl := newSyslogNotifier(t, model.Syslog{Address: conn.LocalAddr().String(), Facility: "local3"})
+
+	if err := sl.Notify(syslogDown); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	buf := make([]byte, 4096)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("failed to read datagram: %v", err)
+	}
+	msg := string(buf[:n])
+
+	// local3 (19) * 8 + critical (2) = 154
+	header := regexp.MustCompile(`^<154>1 \d{4}-\d\d-\d\dT\d\d:\d\d:\d\d\.\d{6}Z monitor-1 healthy-api \d+ DOWN \[`)
+	if !header.MatchString(msg) {
+		t.Errorf("unexpected header in %q", msg)
+	}
+	sd := `[healthcheck@32473 service="api" url="https://api.example.com/health" state="DOWN" severity="critical" ` +
+		`reason="Body \"status\" is not \"ok\" [got \"fail\"\] second line" status_code="503" response_time="1.2s"]`
+	if !strings.Contains(msg, sd) {
+		t.Errorf("message %q does not contain %q", msg, sd)
+	}
+	if !strings.HasSuffix(msg, "] \ufeffapi is DOWN: "+`Body "status" is not "ok" [got "fail"]`) {
+		t.Errorf("unexpected MSG in %q", msg)
+	}
+}
+
+func TestSyslogNotifier_TCPOctetCounting(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer ln.Close()
+	sl := newSyslogNotifier(t, model.Syslog{Network: "tcp", Address: ln.Addr().String(), SDID: "monitor@12345"})
+
+	messages := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		var got []string
+		for range 2 {
+			msg, err := readOctetCounted(r)
+			if err != nil {
+				t.Errorf("failed to read frame: %v", err)
+				return
+			}
+			got = append(got, msg)
+		}
+		messages <- got
+	}()
+
+	warning := model.Notification{ServiceName: "api", Reason: "slow", State: model.StateDegraded, Severity: model.SeverityWarning}
+	recovery := model.Notification{ServiceName: "api", Reason: "Service is healthy again after 2m0s", State: model.StateUp}
+	for _, n := range []model.Notification{warning, recovery} {
+		if err := sl.Notify(n); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	select {
+	case got := <-messages:
+		// daemon (3) * 8 + warning (4) = 28, + notice (5) = 29
+		if !strings.HasPrefix(got[0], "<28>1 ") || !strings.Contains(got[0], ` DEGRADED [monitor@12345 service="api" state="DEGRADED" severity="warning" reason="slow"]`) {
+			t.Errorf("unexpected warning message %q", got[0])
+		}
+		if !strings.HasPrefix(got[1], "<29>1 ") || !strings.Contains(got[1], " UP [monitor@12345 ") {
+			t.Errorf("unexpected recovery message %q", got[1])
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for messages on one connection")
+	}
+}
+
+func TestSyslogNotifier_TLS(t *testing.T) {
+	certPEM, keyPEM := selfSignedCert(t)
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatalf("failed to load key pair: %v", err)
+	}
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer ln.Close()
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sl := newSyslogNotifier(t, model.Syslog{
+		Network: "tls",
+		Address: ln.Addr().String(),
+		TLS:     &model.SyslogTLS{CAFile: caFile, ServerName: "syslog.example.org"},
+	})
+
+	messages := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		msg, err := readOctetCounted(bufio.NewReader(conn))
+		if err != nil {
+			t.Errorf("failed to read frame: %v", err)
+			return
+		}
+		messages <- msg
+	}()
+
+	if err := sl.Notify(syslogDown); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	select {
+	case msg := <-messages:
+		if !strings.HasPrefix(msg, "<26>1 ") {
+			t.Errorf("unexpected message %q", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the message")
+	}
+}
+
+func TestNewSyslogNotifier_Validates(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for name, cfg := range map[string]model.Syslog{
+		"no address":       {},
+		"unknown network":  {Address: "localhost:514", Network: "sctp"},
+		"unknown facility": {Address: "localhost:514", Facility: "local9"},
+		"invalid sd_id":    {Address: "localhost:514", SDID: "health check"},
+		"tls without tls":  {Address: "localhost:514", TLS: &model.SyslogTLS{InsecureSkipVerify: true}},
+		"missing ca_file":  {Address: "localhost:6514", Network: "tls", TLS: &model.SyslogTLS{CAFile: "/nonexistent/ca.pem"}},
+	} {
+		if _, err := notifier.NewSyslogNotifier(cfg, logger); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func selfSignedCert(t *testing.T) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "syslog.example.org"},
+		DNSNames:              []string{"syslog.example.org"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
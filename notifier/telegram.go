@@ -0,0 +1,165 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"healthy-api/model"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultTelegramAPIBaseURL = "https://api.telegram.org"
+	DefaultBaleAPIBaseURL     = "https://tapi.bale.ai"
+)
+
+// TelegramNotifier sends alerts to chats through a bot. Recipients are chat
+// IDs. It also serves Bale, whose Bot API mirrors Telegram's sendMessage.
+type TelegramNotifier struct {
+	ID             string
+	BotToken       string
+	APIBaseURL     string
+	ParseMode      Markup
+	SilentWarnings bool
+	Template       *MessageTemplate
+	Client         *http.Client
+	Logger         *slog.Logger
+
+	platform string // "telegram" or "bale"
+}
+
+// NewTelegramNotifier checks the config and parses its template.
+func NewTelegramNotifier(cfg model.Telegram, logger *slog.Logger) (*TelegramNotifier, error) {
+	if cfg.BotToken == "" {
+		return nil, fmt.Errorf("bot_token is required")
+	}
+	switch Markup(cfg.ParseMode) {
+	case MarkupPlain, MarkupMarkdownV2, MarkupHTML:
+	default:
+		return nil, fmt.Errorf("unknown parse_mode '%s', use MarkdownV2, HTML or leave it empty", cfg.ParseMode)
+	}
+	tmpl, err := NewMessageTemplate(cfg.Template, Markup(cfg.ParseMode))
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	baseURL := cfg.APIBaseURL
+	if baseURL == "" {
+		baseURL = DefaultTelegramAPIBaseURL
+	}
+	return &TelegramNotifier{
+		ID:             cfg.ID,
+		BotToken:       cfg.BotToken,
+		APIBaseURL:     strings.TrimRight(baseURL, "/"),
+		ParseMode:      Markup(cfg.ParseMode),
+		SilentWarnings: cfg.SilentWarnings == nil || *cfg.SilentWarnings,
+		Template:       tmpl,
+		Client:         newHTTPClient(),
+		Logger:         logger,
+		platform:       "telegram",
+	}, nil
+}
+
+// NewBaleNotifier returns a notifier for a Bale bot. Bale only takes plain
+// text, so values are wrapped in bidi isolates instead of being escaped,
+// and the default template is Persian.
+func NewBaleNotifier(cfg model.Bale, logger *slog.Logger) (*TelegramNotifier, error) {
+	if cfg.BotToken == "" {
+		return nil, fmt.Errorf("bot_token is required")
+	}
+	tmpl, err := NewMessageTemplate(cfg.Template, MarkupRTL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	baseURL := cfg.APIBaseURL
+	if baseURL == "" {
+		baseURL = DefaultBaleAPIBaseURL
+	}
+	return &TelegramNotifier{
+		ID:         cfg.ID,
+		BotToken:   cfg.BotToken,
+		APIBaseURL: strings.TrimRight(baseURL, "/"),
+		Template:   tmpl,
+		Client:     newHTTPClient(),
+		Logger:     logger,
+		platform:   "bale",
+	}, nil
+}
+
+func (t *TelegramNotifier) GetName() string {
+	if t.platform == "bale" {
+		return fmt.Sprintf("BaleNotifier(%s)", t.ID)
+	}
+	return fmt.Sprintf("TelegramNotifier(%s)", t.ID)
+}
+
+func (t *TelegramNotifier) Notify(n model.Notification) error {
+	text, err := t.Template.Render(n)
+	if err != nil {
+		return fmt.Errorf("failed to render %s message: %w", t.platform, err)
+	}
+	var errs []error
+	for _, chatID := range n.Recipients {
+		req := model.TelegramSendMessageRequest{
+			ChatID:              chatID,
+			Text:                text,
+			ParseMode:           string(t.ParseMode),
+			DisableNotification: t.SilentWarnings && n.Severity == model.SeverityWarning,
+		}
+		if t.platform == "telegram" {
+			req.LinkPreviewOptions = &model.TelegramLinkPreviewOptions{IsDisabled: true}
+		}
+		if err := t.send(req); err != nil {
+			t.Logger.Error("bot_send_failed", "platform", t.platform, "notifier", t.ID, "chat_id", chatID, "error", err)
+			errs = append(errs, fmt.Errorf("chat %s: %w", chatID, err))
+			continue
+		}
+		t.Logger.Info("bot_message_sent", "platform", t.platform, "notifier", t.ID, "chat_id", chatID, "service", n.ServiceName)
+	}
+	return errors.Join(errs...)
+}
+
+// Resolve sends the recovery message; the template renders it with State UP.
+func (t *TelegramNotifier) Resolve(n model.Notification) error {
+	return t.Notify(n)
+}
+
+// send posts the message, waiting and retrying when the Bot API answers 429
+// with retry_after.
+func (t *TelegramNotifier) send(msg model.TelegramSendMessageRequest) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal sendMessage request: %w", err)
+	}
+	endpoint := fmt.Sprintf("%s/bot%s/sendMessage", t.APIBaseURL, t.BotToken)
+	status, data, err := sendWithRetry(t.Client, t.Logger, t.GetName(), func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, nil
+	}, telegramRetryAfter)
+	if err != nil {
+		return err
+	}
+	var result model.TelegramResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("unexpected response (status %d): %s", status, truncate(string(data), 200))
+	}
+	if !result.OK {
+		return fmt.Errorf("%s error %d: %s", t.platform, result.ErrorCode, result.Description)
+	}
+	return nil
+}
+
+func telegramRetryAfter(body []byte) time.Duration {
+	var result model.TelegramResponse
+	if json.Unmarshal(body, &result) != nil || result.Parameters == nil {
+		return 0
+	}
+	return time.Duration(result.Parameters.RetryAfter) * time.Second
+}
@@ -0,0 +1,152 @@
+package notifier_test
+
+import (
+	"encoding/json"
+	"healthy-api/model"
+	"healthy-api/notifier"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTelegramServer(t *testing.T, handle func(w http.ResponseWriter, req model.TelegramSendMessageRequest)) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bot123:abc/sendMessage" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		var req model.TelegramSendMessageRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request body: %v", err)
+		}
+		handle(w, req)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTelegramNotifier(t *testing.T, cfg model.Telegram) *notifier.TelegramNotifier {
+	t.Helper()
+	cfg.BotToken = "123:abc"
+	tg, err := notifier.NewTelegramNotifier(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return tg
+}
+
+func TestTelegramNotifier_EscapesMarkdownV2(t *testing.T) {
+	var received []model.TelegramSendMessageRequest
+	server := newTelegramServer(t, func(w http.ResponseWriter, req model.TelegramSendMessageRequest) {
+		received = append(received, req)
+		w.Write([]byte(`{"ok":true,"result":{}}`))
+	})
+	tg := newTelegramNotifier(t, model.Telegram{ID: "tg", APIBaseURL: server.URL, ParseMode: "MarkdownV2"})
+
+	err := tg.Notify(model.Notification{
+		ServiceName:  "user-service.v2",
+		Recipients:   []string{"-1001", "@ops"},
+		Reason:       "Status code 503 (expected 200)",
+		StatusCode:   503,
+		ResponseTime: "1.2s",
+		State:        model.StateDown,
+		Severity:     model.SeverityCritical,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(received) != 2 || received[0].ChatID != "-1001" || received[1].ChatID != "@ops" {
+		t.Fatalf("unexpected requests: %+v", received)
+	}
+	msg := received[0]
+	if msg.ParseMode != "MarkdownV2" || msg.DisableNotification {
+		t.Errorf("unexpected options: %+v", msg)
+	}
+	for _, want := range []string{`*user\-service\.v2* is *DOWN*`, `Status code 503 \(expected 200\)`, `Response time: 1\.2s`} {
+		if !strings.Contains(msg.Text, want) {
+			t.Errorf("message %q does not contain %q", msg.Text, want)
+		}
+	}
+}
+
+func TestTelegramNotifier_TemplateAndSilentWarnings(t *testing.T) {
+	var received model.TelegramSendMessageRequest
+	server := newTelegramServer(t, func(w http.ResponseWriter, req model.TelegramSendMessageRequest) {
+		received = req
+		w.Write([]byte(`{"ok":true,"result":{}}`))
+	})
+	tg := newTelegramNotifier(t, model.Telegram{
+		ID:         "tg",
+		APIBaseURL: server.URL + "/",
+		ParseMode:  "HTML",
+		Template:   "<b>{{.ServiceName}}</b> {{.Severity}}: {{.Reason}}",
+	})
+
+	err := tg.Notify(model.Notification{
+		ServiceName: "a<b>",
+		Recipients:  []string{"42"},
+		Reason:      "body & headers",
+		State:       model.StateDegraded,
+		Severity:    model.SeverityWarning,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if received.Text != "<b>a&lt;b&gt;</b> warning: body &amp; headers" {
+		t.Errorf("unexpected text: %q", received.Text)
+	}
+	if !received.DisableNotification {
+		t.Error("warnings should be sent silently")
+	}
+}
+
+func TestTelegramNotifier_RetriesAfter429(t *testing.T) {
+	calls := 0
+	server := newTelegramServer(t, func(w http.ResponseWriter, req model.TelegramSendMessageRequest) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 1","parameters":{"retry_after":1}}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true,"result":{}}`))
+	})
+	tg := newTelegramNotifier(t, model.Telegram{ID: "tg", APIBaseURL: server.URL})
+
+	if err := tg.Notify(model.Notification{ServiceName: "svc", Recipients: []string{"42"}, State: model.StateDown}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 calls, got %d", calls)
+	}
+}
+
+func TestTelegramNotifier_ReportsAPIErrors(t *testing.T) {
+	server := newTelegramServer(t, func(w http.ResponseWriter, req model.TelegramSendMessageRequest) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"ok":false,"error_code":400,"description":"Bad Request: chat not found"}`))
+	})
+	tg := newTelegramNotifier(t, model.Telegram{ID: "tg", APIBaseURL: server.URL})
+
+	err := tg.Notify(model.Notification{ServiceName: "svc", Recipients: []string{"42"}, State: model.StateDown})
+	if err == nil || !strings.Contains(err.Error(), "chat not found") {
+		t.Fatalf("expected chat not found error, got %v", err)
+	}
+}
+
+func TestNewTelegramNotifier_RejectsBadConfig(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cases := []model.Telegram{
+		{ID: "no-token"},
+		{ID: "mode", BotToken: "x", ParseMode: "Markdown"},
+		{ID: "field", BotToken: "x", Template: "{{.Missing}}"},
+	}
+	for _, cfg := range cases {
+		if _, err := notifier.NewTelegramNotifier(cfg, logger); err == nil {
+			t.Errorf("%s: expected an error", cfg.ID)
+		}
+	}
+}
@@ -107,6 +107,10 @@ func (w *WebhookNotifier) Notify(n model.Notification) error {
 			ServiceName: n.ServiceName,
 			TimeStamp:   time.Now().Format(time.RFC3339),
 			URL:         recipient,
+			Timing:      n.Timing.String(),
+			Trace:       n.Trace.String(),
+			State:       string(n.State),
+			Severity:    string(n.Severity),
 		}
 		filledHeaders, err := FillTemplate(w.HookData.Headers, ctx)
 		if err != nil {